@@ -0,0 +1,183 @@
+package deps
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// Parent is implemented by the runtimes, which can have children.
+type Parent interface {
+	// Child returns a child runtime, inheriting the singletons of the
+	// runtime except the overridden ones.
+	Child(Overrides) (Runtime, error)
+	// Deps returns the deps of the runtime, including the ones of the
+	// modules and the decorators, once the conditions are evaluated. They
+	// can be passed to Overrides.Deps.
+	Deps() []*Dep
+}
+
+// Overrides are the differences of a child runtime from its parent. See
+// Parent.Child.
+type Overrides struct {
+	// Deps replace the deps of the parent with the same id, or are added to
+	// the child. Their conditions are evaluated with the config of the child.
+	Deps []*Dep
+	// Config contains TOML sections, replacing the sections of the parent
+	// with the same name.
+	Config string
+	// Present replaces the instances of the deps with the given ids.
+	Present map[string]any
+}
+
+// Child returns a child runtime, for example for a tenant, inheriting the
+// singletons of the runtime except the overridden ones.
+//
+// A singleton is constructed by the child instead of being inherited if it
+// is replaced by overrides.Deps or overrides.Present, if one of its config
+// sections is in overrides.Config, or if it refers, directly or not, to such
+// a singleton. The Shutdown method of the child only shuts down the instances
+// it constructed.
+//
+//	tenant, err := runtime.(deps.Parent).Child(deps.Overrides{
+//		Config: `
+//		[db]
+//		dsn = "tenant-a"
+//		`,
+//	})
+func (r *runtime) Child(overrides Overrides) (Runtime, error) {
+	sections, err := ParseTOML(overrides.Config)
+	if err != nil {
+		return nil, err
+	}
+	merged := maps.Clone(r.sections)
+	maps.Copy(merged, sections)
+	rtConfig, err := parseRuntimeConfig(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	overrideDeps, _, err := selectDeps(overrides.Deps, newConfigView(r.config, merged, rtConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := map[string]bool{}
+	for _, dep := range overrideDeps {
+		replaced[dep.id] = true
+	}
+	var deps []*Dep
+	for id, dep := range r.depsByName {
+		if !replaced[id] {
+			deps = append(deps, dep)
+		}
+	}
+	for _, ds := range r.decorators {
+		for _, d := range ds {
+			if !replaced[d.id] {
+				deps = append(deps, d)
+			}
+		}
+	}
+	deps = append(deps, overrideDeps...)
+	deps, decorators := splitDecorators(deps)
+	depsByName, depsByIntf, depsByImpl, err := indexDeps(deps)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rtConfig.validateBindings(depsByIntf, decorators); err != nil {
+		return nil, err
+	}
+	if err := validateDecorators(decorators, depsByIntf); err != nil {
+		return nil, err
+	}
+
+	owned := map[string]bool{}
+	for id := range replaced {
+		owned[id] = true
+	}
+	for id := range overrides.Present {
+		owned[id] = true
+	}
+	configured := slices.Clone(deps)
+	for _, ds := range decorators {
+		configured = append(configured, ds...)
+	}
+	for _, dep := range configured {
+		id := dep.id
+		subs := sections
+		if dep.module != "" {
+			if subs, err = moduleSections(sections, dep.module); err != nil {
+				return nil, err
+			}
+		}
+		for _, key := range configKeys(dep) {
+			if _, ok := subs[key]; ok {
+				owned[id] = true
+			}
+		}
+	}
+
+	impls := map[string]any{}
+	maps.Copy(impls, overrides.Present)
+
+	ctx, cancel := context.WithCancelCause(r.ctx)
+	return &runtime{
+		depsByName: depsByName,
+		depsByIntf: depsByIntf,
+		depsByImpl: depsByImpl,
+		decorators: decorators,
+		ctx:        ctx,
+		cancel:     cancel,
+		config:     r.config,
+		sections:   merged,
+		rtConfig:   rtConfig,
+		impls:      impls,
+		decorated:  map[string]any{},
+		edges:      map[string][]string{},
+		parent:     r,
+		owned:      owned,
+	}, nil
+}
+
+func (r *runtime) Deps() []*Dep {
+	deps := make([]*Dep, 0, len(r.depsByName))
+	for _, dep := range r.depsByName {
+		deps = append(deps, dep)
+	}
+	for _, ds := range r.decorators {
+		deps = append(deps, ds...)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].id < deps[j].id })
+	return deps
+}
+
+// owns returns true if the singleton dep is constructed by the runtime
+// rather than inherited from its parent.
+func (r *runtime) owns(dep *Dep) bool {
+	if r.owned == nil {
+		// A scope.
+		return false
+	}
+	if owned, ok := r.owned[dep.id]; ok {
+		return owned
+	}
+	r.owned[dep.id] = false // guards against cycles
+	if !dep.decorator {
+		for _, d := range r.decoratorsOf(dep) {
+			if r.owns(d) {
+				r.owned[dep.id] = true
+				return true
+			}
+		}
+	}
+	for _, ref := range depRefs(dep) {
+		if d, err := r.resolveRef(ref, dep.name); err == nil && r.owns(d) {
+			r.owned[dep.id] = true
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package deps
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type childDB interface{}
+
+type childDBConfig struct {
+	DSN string
+}
+
+type childDBImpl struct {
+	Implements[childDB]
+	WithConfig[childDBConfig] `section:"db"`
+	closed                    bool
+}
+
+func (d *childDBImpl) Shutdown(context.Context) error {
+	d.closed = true
+	return nil
+}
+
+type otherDBImpl struct {
+	Implements[childDB]
+}
+
+type childRepo interface{}
+
+type childRepoImpl struct {
+	Implements[childRepo]
+	db Ref[childDB]
+}
+
+type childLog interface{}
+
+type childLogImpl struct {
+	Implements[childLog]
+}
+
+func TestChild(t *testing.T) {
+	present := &childDBImpl{}
+	for _, tc := range []struct {
+		name      string
+		overrides Overrides
+		owned     map[string]bool // the components constructed by the child
+		dsn       string          // the DSN of the db of the child, if a childDBImpl
+	}{
+		{
+			name:  "no overrides",
+			owned: map[string]bool{},
+			dsn:   "root",
+		},
+		{
+			name:      "config",
+			overrides: Overrides{Config: "[db]\nDSN = \"tenant\"\n"},
+			owned:     map[string]bool{"db": true, "repo": true},
+			dsn:       "tenant",
+		},
+		{
+			name:      "deps",
+			overrides: Overrides{Deps: []*Dep{mustDep(NewDep[childDB, otherDBImpl]())}},
+			owned:     map[string]bool{"db": true, "repo": true},
+		},
+		{
+			name:      "present",
+			overrides: Overrides{Present: map[string]any{typeName(Type[childDB]()): present}},
+			owned:     map[string]bool{"db": true, "repo": true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testRuntime(t, Config{Config: "[db]\nDSN = \"root\"\n"},
+				mustDep(NewDep[childDB, childDBImpl]()),
+				mustDep(NewDep[childRepo, childRepoImpl]()),
+				mustDep(NewDep[childLog, childLogImpl]()),
+			)
+			child, err := r.Child(tc.overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			types := map[string]reflect.Type{
+				"db":   Type[childDB](),
+				"repo": Type[childRepo](),
+				"log":  Type[childLog](),
+			}
+			// Constructed in the child first, so that the inherited
+			// components are constructed by the parent on demand.
+			for _, name := range []string{"repo", "db", "log"} {
+				got, err := child.GetIntf(types[name], "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, err := r.GetIntf(types[name], "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if owned := got != want; owned != tc.owned[name] {
+					t.Errorf("%s constructed by the child: %v, want %v", name, owned, tc.owned[name])
+				}
+			}
+
+			if db, _ := child.GetIntf(Type[childDB](), ""); tc.dsn != "" {
+				if dsn := db.(*childDBImpl).Config().DSN; dsn != tc.dsn {
+					t.Errorf("DSN of the child = %q, want %q", dsn, tc.dsn)
+				}
+			}
+			db, _ := r.GetIntf(Type[childDB](), "")
+			if dsn := db.(*childDBImpl).Config().DSN; dsn != "root" {
+				t.Errorf("DSN of the parent = %q, want root", dsn)
+			}
+			if err := child.(Shutdowner).Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if db.(*childDBImpl).closed {
+				t.Error("the child shut down the db of its parent")
+			}
+		})
+	}
+}
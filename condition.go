@@ -0,0 +1,240 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigView is a read-only view of the config, passed to the conditions
+// of the deps. See WithCondition.
+type ConfigView interface {
+	// Section returns the TOML of the section with the given name.
+	Section(name string) (string, bool)
+	// Decode decodes the section with the given name into v. Unknown keys
+	// are ignored. It does nothing if there is no such section.
+	Decode(name string, v any) error
+	// Profiles returns the active profiles.
+	Profiles() []string
+	// HasProfile returns true if the given profile is active.
+	HasProfile(string) bool
+}
+
+// configView implements ConfigView.
+type configView struct {
+	sections map[string]string
+	profiles []string
+}
+
+func (v configView) Section(name string) (string, bool) {
+	s, ok := v.sections[name]
+	return s, ok
+}
+
+func (v configView) Decode(name string, dst any) error {
+	s, ok := v.sections[name]
+	if !ok {
+		return nil
+	}
+	_, err := toml.Decode(s, dst)
+	return err
+}
+
+func (v configView) Profiles() []string {
+	return slices.Clone(v.profiles)
+}
+
+func (v configView) HasProfile(p string) bool {
+	return slices.Contains(v.profiles, p)
+}
+
+// WithCondition registers the Dep conditionally: it is only part of a
+// runtime if cond returns true for the runtime config.
+func WithCondition(cond func(ConfigView) bool) Option {
+	return func(dep *Dep) {
+		dep.condition = cond
+	}
+}
+
+// WithProfiles registers the Dep conditionally: it is only part of a runtime
+// if one of the given profiles is active. The active profiles are set by
+// Config.Profiles and the config:
+//
+//	[deps]
+//	profiles = ["prod"]
+func WithProfiles(profiles ...string) Option {
+	return func(dep *Dep) {
+		dep.profiles = append(dep.profiles, profiles...)
+	}
+}
+
+// conditional returns true if the dep has a condition or profiles.
+func (d *Dep) conditional() bool {
+	return d.condition != nil || len(d.profiles) > 0
+}
+
+// active tells whether the dep is active for the config, and why.
+func (d *Dep) active(view ConfigView) (bool, string) {
+	reason := "unconditional"
+	if len(d.profiles) > 0 {
+		i := slices.IndexFunc(d.profiles, view.HasProfile)
+		if i < 0 {
+			return false, fmt.Sprintf("none of the profiles %v is active", d.profiles)
+		}
+		reason = fmt.Sprintf("profile %q is active", d.profiles[i])
+	}
+	if d.condition != nil {
+		if !d.condition(view) {
+			return false, "condition not met"
+		}
+		if len(d.profiles) > 0 {
+			reason += " and condition met"
+		} else {
+			reason = "condition met"
+		}
+	}
+	return true, reason
+}
+
+// Candidate is an implementation considered for a Selection.
+type Candidate struct {
+	// Impl is the implementation type.
+	Impl string
+	// Active tells whether the implementation was chosen.
+	Active bool
+	// Conditional tells whether the implementation is registered with a
+	// condition or profiles.
+	Conditional bool
+	// Reason tells why the implementation was chosen or not.
+	Reason string
+}
+
+// Selection reports the implementation chosen for an interface and a name
+// among the conditional registrations.
+type Selection struct {
+	// Iface is the interface type.
+	Iface string
+	// Name is the name of the implementation.
+	Name string
+	// Candidates are the registered implementations.
+	Candidates []Candidate
+}
+
+// Chosen returns the chosen implementation, or the empty string if none.
+func (s Selection) Chosen() string {
+	for _, c := range s.Candidates {
+		if c.Active {
+			return c.Impl
+		}
+	}
+	return ""
+}
+
+// String implements fmt.Stringer.
+func (s Selection) String() string {
+	str := fmt.Sprintf("%s (%s):", s.Iface, s.Name)
+	for _, c := range s.Candidates {
+		mark := "-"
+		if c.Active {
+			mark = "+"
+		}
+		str += fmt.Sprintf(" %s%s [%s]", mark, c.Impl, c.Reason)
+	}
+	return str
+}
+
+// selectDeps returns the deps which are active for the config, and the
+// selections made.
+func selectDeps(deps []*Dep, view ConfigView) ([]*Dep, []Selection, error) {
+	type key struct {
+		iface reflect.Type
+		name  string
+	}
+	var active []*Dep
+	selections := map[key]*Selection{}
+	var keys []key
+	for _, dep := range deps {
+		ok, reason := dep.active(view)
+		if ok {
+			active = append(active, dep)
+		}
+		k := key{dep.iface, dep.name}
+		if dep.decorator {
+			k.name = dep.id
+		}
+		s, found := selections[k]
+		if !found {
+			s = &Selection{Iface: dep.iface.String(), Name: dep.name}
+			selections[k] = s
+			keys = append(keys, k)
+		}
+		s.Candidates = append(s.Candidates, Candidate{
+			Impl:        dep.implType().String(),
+			Active:      ok,
+			Conditional: dep.conditional(),
+			Reason:      reason,
+		})
+	}
+
+	var errs []error
+	result := make([]Selection, 0, len(keys))
+	for _, k := range keys {
+		s := selections[k]
+		n := 0
+		for _, c := range s.Candidates {
+			if c.Active {
+				n++
+			}
+		}
+		if n > 1 {
+			errs = append(errs, fmt.Errorf("multiple active implementations for %s", s))
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Iface != result[j].Iface {
+			return result[i].Iface < result[j].Iface
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(errs) > 0 {
+		return nil, result, errors.Join(errs...)
+	}
+	return active, result, nil
+}
+
+// newConfigView returns the view of the given config.
+func newConfigView(config Config, sections map[string]string, rtConfig runtimeConfig) ConfigView {
+	var profiles []string
+	profiles = append(profiles, config.Profiles...)
+	profiles = append(profiles, rtConfig.Profiles...)
+	return configView{sections: sections, profiles: profiles}
+}
+
+// ValidateDepsWithConfig evaluates the conditions of the given registrations,
+// and the ones of Config.Modules, for the config, validates the active ones like ValidateDeps, and reports
+// which implementation was chosen for each interface and name, and why. Unlike ValidateDeps, it checks
+// the single-implementation interfaces against the active conditional registrations too.
+func ValidateDepsWithConfig(deps []*Dep, config Config) ([]Selection, error) {
+	deps, err := withModules(deps, config)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := ParseTOML(config.Config)
+	if err != nil {
+		return nil, err
+	}
+	rtConfig, err := parseRuntimeConfig(sections)
+	if err != nil {
+		return nil, err
+	}
+	active, selections, err := selectDeps(deps, newConfigView(config, sections, rtConfig))
+	if err != nil {
+		return selections, err
+	}
+	return selections, validateDeps(active, true)
+}
@@ -0,0 +1,106 @@
+package deps
+
+import (
+	"strings"
+	"testing"
+)
+
+type condFoo interface{}
+
+type condFooA struct {
+	Implements[condFoo]
+}
+
+type condFooB struct {
+	Implements[condFoo]
+}
+
+func TestValidateDepsWithConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		deps     []*Dep
+		profiles []string
+		chosen   string // the chosen implementation of the anonymous condFoo
+		cond     bool   // the chosen implementation is conditional
+		err      string
+	}{
+		{
+			name: "unconditional",
+			deps: []*Dep{
+				mustDep(NewDep[condFoo, condFooA]()),
+			},
+			chosen: "deps.condFooA",
+		},
+		{
+			name: "profile",
+			deps: []*Dep{
+				mustDep(NewDep[condFoo, condFooA](WithProfiles("dev"))),
+				mustDep(NewDep[condFoo, condFooB](WithProfiles("prod"))),
+			},
+			profiles: []string{"prod"},
+			chosen:   "deps.condFooB",
+			cond:     true,
+		},
+		{
+			name: "condition",
+			deps: []*Dep{
+				mustDep(NewDep[condFoo, condFooA](WithCondition(func(v ConfigView) bool { return !v.HasProfile("prod") }))),
+				mustDep(NewDep[condFoo, condFooB](WithProfiles("prod"))),
+			},
+			chosen: "deps.condFooA",
+			cond:   true,
+		},
+		{
+			name: "multiple active",
+			deps: []*Dep{
+				mustDep(NewDep[condFoo, condFooA](WithProfiles("dev"))),
+				mustDep(NewDep[condFoo, condFooB](WithProfiles("prod"))),
+			},
+			profiles: []string{"dev", "prod"},
+			err:      "multiple active implementations",
+		},
+		{
+			name: "single implementation inactive",
+			deps: []*Dep{
+				mustDep(NewDep[condFoo, condFooA](WithSingleton())),
+				mustDep(NewDep[condFoo, condFooB](WithName("fooB"), WithProfiles("prod"))),
+			},
+			chosen: "deps.condFooA",
+		},
+		{
+			name: "single implementation active",
+			deps: []*Dep{
+				mustDep(NewDep[condFoo, condFooA](WithSingleton())),
+				mustDep(NewDep[condFoo, condFooB](WithName("fooB"), WithProfiles("prod"))),
+			},
+			profiles: []string{"prod"},
+			err:      "single-implementation but has 2 implementations",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			selections, err := ValidateDepsWithConfig(tc.deps, Config{Profiles: tc.profiles})
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("ValidateDepsWithConfig() = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range selections {
+				if s.Name != typeName(Type[condFoo]()) {
+					continue
+				}
+				if got := s.Chosen(); got != tc.chosen {
+					t.Errorf("chosen %q, want %q", got, tc.chosen)
+				}
+				for _, c := range s.Candidates {
+					if c.Active && c.Conditional != tc.cond {
+						t.Errorf("%s conditional: %v, want %v", c.Impl, c.Conditional, tc.cond)
+					}
+				}
+			}
+		})
+	}
+}
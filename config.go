@@ -1,8 +1,10 @@
 package deps
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -10,6 +12,116 @@ import (
 
 const PkgPath = "github.com/cgfork/deps"
 
+// RuntimeSection is the name of the config section of the runtime itself.
+const RuntimeSection = "deps"
+
+// runtimeConfig is the config of the runtime itself, found in the
+// RuntimeSection section.
+type runtimeConfig struct {
+	// Restart contains the restart policies, keyed by dep name.
+	Restart map[string]RestartPolicy `toml:"restart"`
+	// Bindings selects the implementations received by the refs. The keys
+	// are the full names of the interfaces for the refs without tag, like
+	// "main.Foo", or the full names followed by "$" and the ref tag for the
+	// named refs, like "main.Foo$fooA". The values are the names of the
+	// implementations, the full name of the interface for the anonymous one.
+	Bindings map[string]string `toml:"bindings"`
+	// Rebind is like Bindings but only applies to the refs of the consumer,
+	// whose name is the key, like "fooA" or, for an anonymous
+	// implementation, the full name of its interface. It takes precedence
+	// over Bindings.
+	Rebind map[string]map[string]string `toml:"rebind"`
+	// Profiles are the active profiles, in addition to Config.Profiles.
+	Profiles []string `toml:"profiles"`
+}
+
+// bind returns the name of the implementation of the interface t bound to
+// the ref with the given name in the requester.
+func (c *runtimeConfig) bind(t reflect.Type, name, requester string) string {
+	key := typeName(t)
+	if name != "" {
+		key += "$" + name
+	}
+	if b, ok := c.Rebind[requester][key]; ok {
+		return b
+	}
+	if b, ok := c.Bindings[key]; ok {
+		return b
+	}
+	return name
+}
+
+// validateBindings checks that the bindings refer to registered
+// implementations, and that the rebound consumers are registered deps or
+// decorators.
+func (c *runtimeConfig) validateBindings(depsByIntf map[reflect.Type]map[string]*Dep, decorators map[reflect.Type][]*Dep) error {
+	intfs := map[string]map[string]*Dep{}
+	consumers := map[string]bool{}
+	for t, deps := range depsByIntf {
+		intfs[typeName(t)] = deps
+		for name := range deps {
+			consumers[name] = true
+		}
+	}
+	for _, ds := range decorators {
+		for _, d := range ds {
+			consumers[d.name] = true
+		}
+	}
+	check := func(key, target string) error {
+		iface, _, _ := strings.Cut(key, "$")
+		deps, ok := intfs[iface]
+		if !ok {
+			return fmt.Errorf("binding %q: interface %s not registered", key, iface)
+		}
+		if _, ok := deps[target]; !ok {
+			return fmt.Errorf("binding %q: implementation %q of %s not registered", key, target, iface)
+		}
+		return nil
+	}
+
+	var errs []error
+	for key, target := range c.Bindings {
+		errs = append(errs, check(key, target))
+	}
+	for consumer, bindings := range c.Rebind {
+		if !consumers[consumer] {
+			names := make([]string, 0, len(consumers))
+			for name := range consumers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			errs = append(errs, fmt.Errorf("rebind: consumer %q not registered; the registered consumers are %q", consumer, names))
+			continue
+		}
+		for key, target := range bindings {
+			if err := check(key, target); err != nil {
+				errs = append(errs, fmt.Errorf("consumer %q: %w", consumer, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate validates the runtime config.
+func (c *runtimeConfig) Validate() error {
+	for name, p := range c.Restart {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("restart policy of %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// parseRuntimeConfig decodes the RuntimeSection section.
+func parseRuntimeConfig(sections map[string]string) (runtimeConfig, error) {
+	var c runtimeConfig
+	if err := unmarshalTOML(RuntimeSection, "", sections, &c); err != nil {
+		return runtimeConfig{}, err
+	}
+	return c, nil
+}
+
 // WithConfig[T] is a type that can be embedded inside a implementation struct.
 // implementation. The runtime will take per-construct configuration information
 // found in the application config file and use it to initialize the contents of T.
@@ -35,19 +147,33 @@ func (wc *WithConfig[T]) xxx_getConfig() any {
 	return &wc.config
 }
 
-func setupConfig(name string, value reflect.Value, sections map[string]string) error {
+// setupConfig decodes the config of the implementation value. The section
+// overrides the section tag of the deps.WithConfig field if not empty.
+func setupConfig(name, section string, value reflect.Value, sections map[string]string) error {
 	v, shortKey := resolveConfigAndName(value)
 	if v == nil {
 		return nil
 	}
+	if section != "" {
+		shortKey = section
+	}
+	return loadConfig(name, shortKey, sections, v)
+}
 
+// loadConfig decodes the section of the dep with the given name into v and
+// validates it.
+func loadConfig(name, shortKey string, sections map[string]string, v any) error {
 	if err := unmarshalTOML(name, shortKey, sections, v); err != nil {
 		return err
 	}
 
 	if x, ok := v.(interface{ Validate() error }); ok {
 		if err := x.Validate(); err != nil {
-			return fmt.Errorf("validate %T: %w", x, err)
+			section := shortKey
+			if section == "" {
+				section = name
+			}
+			return &ConfigError{Section: section, Err: fmt.Errorf("validate %T: %w", x, err)}
 		}
 	}
 	return nil
@@ -64,9 +190,7 @@ func resolveConfigAndName(v reflect.Value) (any, string) {
 	for i := 0; i < t.NumField(); i++ {
 		// Check that f is an embedded field of type deps.WithConfig[T].
 		f := t.Field(i)
-		if !f.Anonymous ||
-			f.Type.PkgPath() != PkgPath ||
-			!strings.HasPrefix(f.Type.Name(), "WithConfig[") {
+		if !isWithConfig(f) {
 			continue
 		}
 
@@ -79,13 +203,42 @@ func resolveConfigAndName(v reflect.Value) (any, string) {
 	return nil, ""
 }
 
+// isWithConfig returns true if f is an embedded field of type deps.WithConfig[T].
+func isWithConfig(f reflect.StructField) bool {
+	return f.Anonymous &&
+		f.Type.PkgPath() == PkgPath &&
+		strings.HasPrefix(f.Type.Name(), "WithConfig[")
+}
+
+// configKeys returns the names of the config sections which may configure
+// the dep.
+func configKeys(dep *Dep) []string {
+	keys := []string{dep.name}
+	if dep.section != "" {
+		return append(keys, dep.section)
+	}
+	if dep.impl == nil {
+		return keys
+	}
+	for i := 0; i < dep.impl.NumField(); i++ {
+		f := dep.impl.Field(i)
+		if !isWithConfig(f) {
+			continue
+		}
+		if section := f.Tag.Get("section"); section != "" && section != dep.name {
+			keys = append(keys, section)
+		}
+	}
+	return keys
+}
+
 // unmarshalTOML decodes the specified TOML section into dst.
 func unmarshalTOML(key, shortKey string, sections map[string]string, dst any) error {
 	section, ok := sections[key]
 	if shortKey != "" && shortKey != key {
 		if sSection, ok2 := sections[shortKey]; ok2 {
 			if ok {
-				return fmt.Errorf("confliction sections %q and %q", shortKey, key)
+				return &ConfigError{Section: key, Err: fmt.Errorf("confliction sections %q and %q", shortKey, key)}
 			}
 			key, section, ok = shortKey, sSection, ok2
 		}
@@ -97,16 +250,16 @@ func unmarshalTOML(key, shortKey string, sections map[string]string, dst any) er
 
 	md, err := toml.Decode(section, dst)
 	if err != nil {
-		return err
+		return decodeError(key, err)
 	}
 
 	if unknown := md.Undecoded(); len(unknown) != 0 {
-		return fmt.Errorf("section %q has unknown keys %v", key, unknown)
+		return &ConfigError{Section: key, Key: unknown[0].String(), Err: fmt.Errorf("unknown keys %v", unknown)}
 	}
 
 	if x, ok := dst.(interface{ Validate() error }); ok {
 		if err := x.Validate(); err != nil {
-			return fmt.Errorf("section %q: %w", key, err)
+			return &ConfigError{Section: key, Err: err}
 		}
 	}
 	return nil
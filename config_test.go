@@ -0,0 +1,119 @@
+package deps
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type bindFoo interface{}
+
+type bindFooA struct {
+	Implements[bindFoo] `impl:"fooA"`
+}
+
+type bindFooB struct {
+	Implements[bindFoo] `impl:"fooB"`
+}
+
+type bindBar interface{}
+
+type bindBarImpl struct {
+	Implements[bindBar] `impl:"bar"`
+	foo                 Ref[bindFoo] `ref:"fooA"`
+}
+
+type bindBaz interface{}
+
+type bindBazImpl struct {
+	Implements[bindBaz] `impl:"baz"`
+	foo                 Ref[bindFoo] `ref:"fooA"`
+}
+
+func TestBindings(t *testing.T) {
+	foo := typeName(Type[bindFoo]())
+	for _, tc := range []struct {
+		name   string
+		config string
+		bar    string // the name of the foo of bar
+		baz    string // the name of the foo of baz
+		err    string
+	}{
+		{
+			name: "none",
+			bar:  "fooA",
+			baz:  "fooA",
+		},
+		{
+			name:   "binding",
+			config: "[deps.bindings]\n'" + foo + "$fooA' = 'fooB'\n",
+			bar:    "fooB",
+			baz:    "fooB",
+		},
+		{
+			name:   "rebind",
+			config: "[deps.rebind.bar]\n'" + foo + "$fooA' = 'fooB'\n",
+			bar:    "fooB",
+			baz:    "fooA",
+		},
+		{
+			name:   "unknown interface",
+			config: "[deps.bindings]\n'main.Foo' = 'fooB'\n",
+			err:    `binding "main.Foo": interface main.Foo not registered`,
+		},
+		{
+			name:   "unknown implementation",
+			config: "[deps.bindings]\n'" + foo + "' = 'fooC'\n",
+			err:    `implementation "fooC"`,
+		},
+		{
+			name:   "unknown consumer",
+			config: "[deps.rebind.'main.app']\n'" + foo + "' = 'fooB'\n",
+			err:    `consumer "main.app" not registered; the registered consumers are ["bar" "baz" "fooA" "fooB"]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newRuntime(context.Background(), []*Dep{
+				mustDep(NewDep[bindFoo, bindFooA]()),
+				mustDep(NewDep[bindFoo, bindFooB]()),
+				mustDep(NewDep[bindBar, bindBarImpl]()),
+				mustDep(NewDep[bindBaz, bindBazImpl]()),
+			}, Config{Config: tc.config})
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("newRuntime() = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bar, err := r.GetIntf(Type[bindBar](), "bar")
+			if err != nil {
+				t.Fatal(err)
+			}
+			baz, err := r.GetIntf(Type[bindBaz](), "baz")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := implName(bar.(*bindBarImpl).foo.Get()); got != tc.bar {
+				t.Errorf("foo of bar = %s, want %s", got, tc.bar)
+			}
+			if got := implName(baz.(*bindBazImpl).foo.Get()); got != tc.baz {
+				t.Errorf("foo of baz = %s, want %s", got, tc.baz)
+			}
+		})
+	}
+}
+
+// implName returns the name of the bindFoo implementation foo.
+func implName(foo bindFoo) string {
+	switch foo.(type) {
+	case *bindFooA:
+		return "fooA"
+	case *bindFooB:
+		return "fooB"
+	}
+	return "?"
+}
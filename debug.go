@@ -0,0 +1,306 @@
+package deps
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DepInfo describes a registered dep and its state in a runtime.
+type DepInfo struct {
+	// ID is the unique id of the dep.
+	ID string `json:"id"`
+	// Name is the human-readable name of the dep.
+	Name string `json:"name"`
+	// Module is the name of the module defining the dep, if any.
+	Module string `json:"module,omitempty"`
+	// Iface is the interface type.
+	Iface string `json:"iface"`
+	// Impl is the implementation type.
+	Impl string `json:"impl"`
+	// Decorator is true if the dep decorates the implementations of Iface.
+	Decorator bool `json:"decorator,omitempty"`
+	// Constructed is true if the implementation has been constructed.
+	Constructed bool `json:"constructed"`
+	// Config is the config of the constructed implementation with the
+	// sensitive values redacted.
+	Config any `json:"config,omitempty"`
+	// Refs contains the ids of the referenced deps. For the constructed
+	// implementations these are the resolved refs, otherwise the refs
+	// which would be resolved.
+	Refs []string `json:"refs,omitempty"`
+	// Restarts is the number of restarts of the background service.
+	Restarts int `json:"restarts,omitempty"`
+	// Timing is the construction timing, if constructed.
+	Timing *InitTiming `json:"-"`
+}
+
+// Describer is implemented by the runtimes. Describe returns the registered
+// deps, including the decorators, and their state in the runtime.
+type Describer interface {
+	Describe() []DepInfo
+}
+
+func (r *runtime) Describe() []DepInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timings := map[string]InitTiming{}
+	decorators := map[string]any{} // decorator id to its last constructed instance
+	for _, t := range r.timeline {
+		timings[t.ID] = t
+	}
+	for _, b := range r.order {
+		if b.dep.decorator {
+			decorators[b.dep.id] = b.obj
+		}
+	}
+
+	deps := make([]*Dep, 0, len(r.depsByName))
+	for _, dep := range r.depsByName {
+		deps = append(deps, dep)
+	}
+	for _, ds := range r.decorators {
+		deps = append(deps, ds...)
+	}
+
+	infos := make([]DepInfo, 0, len(deps))
+	for _, dep := range deps {
+		id := dep.id
+		info := DepInfo{
+			ID:        id,
+			Name:      dep.name,
+			Module:    dep.module,
+			Iface:     dep.iface.String(),
+			Impl:      dep.implType().String(),
+			Decorator: dep.decorator,
+		}
+		impl, ok := r.impls[id]
+		if dep.decorator {
+			impl, ok = decorators[id]
+			// A decorator refers to the implementations it decorates.
+			for _, d := range r.depsByIntf[dep.iface] {
+				if dep.decorates(d) {
+					info.Refs = append(info.Refs, d.id)
+				}
+			}
+			sort.Strings(info.Refs)
+		}
+		if ok {
+			info.Constructed = true
+			info.Config = redact(GetConfig(impl))
+			info.Refs = append(info.Refs, r.edges[id]...)
+		} else {
+			for _, ref := range depRefs(dep) {
+				if d, err := r.resolveRef(ref, dep.name); err == nil {
+					info.Refs = append(info.Refs, d.id)
+				}
+			}
+		}
+		if t, ok := timings[id]; ok {
+			info.Timing = &t
+		}
+		info.Restarts = r.servers.restartCount(id)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// sensitiveNames contains the substrings of the config keys whose values
+// are redacted.
+var sensitiveNames = []string{"password", "passwd", "secret", "token", "credential", "apikey", "api_key", "privatekey", "private_key"}
+
+// redact converts the config v into a JSON-friendly value, replacing the
+// values of the sensitive fields with a placeholder. A field is sensitive
+// if it is tagged with `deps:"secret"` or its name looks like a secret.
+func redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(v))
+}
+
+func redactValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		m := map[string]any{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			key := f.Name
+			if tag, _, _ := strings.Cut(f.Tag.Get("toml"), ","); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				key = tag
+			}
+			if isSensitive(f) {
+				m[key] = "REDACTED"
+				continue
+			}
+			m[key] = redactValue(v.Field(i))
+		}
+		return m
+	case reflect.Map:
+		m := map[string]any{}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := strings.ToLower(iter.Key().String())
+			if containsAny(key, sensitiveNames) {
+				m[iter.Key().String()] = "REDACTED"
+				continue
+			}
+			m[iter.Key().String()] = redactValue(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = redactValue(v.Index(i))
+		}
+		return s
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return v.Type().String()
+	default:
+		return v.Interface()
+	}
+}
+
+func isSensitive(f reflect.StructField) bool {
+	if f.Tag.Get("deps") == "secret" {
+		return true
+	}
+	return containsAny(strings.ToLower(f.Name), sensitiveNames)
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugTiming is the JSON view of InitTiming.
+type debugTiming struct {
+	Start  string `json:"start"`
+	Config string `json:"config"`
+	Refs   string `json:"refs"`
+	Init   string `json:"init"`
+	Total  string `json:"total"`
+}
+
+// debugDep is the JSON view of DepInfo.
+type debugDep struct {
+	DepInfo
+	ConfigJSON string       `json:"-"`
+	Timing     *debugTiming `json:"timing,omitempty"`
+}
+
+// debugState is the JSON view served by DebugHandler.
+type debugState struct {
+	Deps   []debugDep   `json:"deps"`
+	Health HealthStatus `json:"health"`
+}
+
+// DebugHandler returns a http.Handler which serves a view of the runtime:
+// the registered deps, the constructed implementations with their redacted
+// config, the dependency edges, the construction timings and the health
+// status. The view is HTML by default, and JSON if the request has the
+// query parameter format=json or accepts application/json. It panics if r
+// doesn't implement Describer and HealthReporter.
+func DebugHandler(r Runtime) http.Handler {
+	d := capability[interface {
+		Describer
+		HealthReporter
+	}](r, "DebugHandler")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		state := debugState{Health: d.Health(req.Context())}
+		for _, info := range d.Describe() {
+			d := debugDep{DepInfo: info}
+			if info.Config != nil {
+				b, _ := json.MarshalIndent(info.Config, "", "  ")
+				d.ConfigJSON = string(b)
+			}
+			if t := info.Timing; t != nil {
+				d.Timing = &debugTiming{
+					Start:  t.Start.Format("15:04:05.000000"),
+					Config: t.Config.String(),
+					Refs:   t.Refs.String(),
+					Init:   t.Init.String(),
+					Total:  t.Total().String(),
+				}
+			}
+			state.Deps = append(state.Deps, d)
+		}
+
+		if req.URL.Query().Get("format") == "json" ||
+			strings.Contains(req.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(state)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := debugTemplate.Execute(w, state); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>deps</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { margin: 0; }
+.fail { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Deps</h1>
+<table>
+<tr><th>ID</th><th>Module</th><th>Interface</th><th>Implementation</th><th>Constructed</th><th>Refs</th><th>Config</th><th>Timing</th><th>Restarts</th></tr>
+{{range .Deps}}
+<tr id="{{.ID}}">
+<td>{{.ID}}</td>
+<td>{{.Module}}</td>
+<td>{{.Iface}}</td>
+<td>{{.Impl}}{{if .Decorator}} (decorator){{end}}</td>
+<td>{{.Constructed}}</td>
+<td>{{range .Refs}}<a href="#{{.}}">{{.}}</a><br>{{end}}</td>
+<td><pre>{{.ConfigJSON}}</pre></td>
+<td>{{with .Timing}}start {{.Start}}<br>config {{.Config}}<br>refs {{.Refs}}<br>init {{.Init}}<br>total {{.Total}}{{end}}</td>
+<td>{{.Restarts}}</td>
+</tr>
+{{end}}
+</table>
+<h1>Health</h1>
+<p>Healthy: {{.Health.Healthy}}</p>
+<table>
+<tr><th>Name</th><th>Result</th><th>Duration</th></tr>
+{{range .Health.Components}}
+<tr><td>{{.Name}}</td><td{{if .Err}} class="fail"{{end}}>{{if .Err}}{{.Err}}{{else}}ok{{end}}</td><td>{{.Duration}}</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,219 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type edgeFoo interface{}
+type edgeBar interface{}
+
+type edgeFooImpl struct {
+	Implements[edgeFoo]
+	bar Ref[edgeBar] `ref:"bar"`
+}
+
+func TestDescribeRefs(t *testing.T) {
+	calls := 0
+	r := testRuntime(t, Config{},
+		mustDep(NewDep[edgeFoo, edgeFooImpl](WithName("foo"))),
+		mustDep(NewFuncDep[edgeBar](func() (edgeBar, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("flaky")
+			}
+			return struct{}{}, nil
+		}, WithName("bar"))),
+	)
+
+	// The failed resolution leaves no edge behind.
+	if _, err := r.GetIntf(Type[edgeFoo](), "foo"); err == nil {
+		t.Fatal("GetIntf succeeded, want the error of bar")
+	}
+	if _, err := r.GetIntf(Type[edgeFoo](), "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := r.Describe()
+	i := slices.IndexFunc(infos, func(info DepInfo) bool { return info.Name == "foo" })
+	got := infos[i].Refs
+	if want := []string{typeName(Type[edgeBar]()) + "$bar"}; !slices.Equal(got, want) {
+		t.Errorf("refs of foo = %v, want %v", got, want)
+	}
+}
+
+type suppliedConfig struct {
+	Addr string
+}
+
+func TestDescribeSupplied(t *testing.T) {
+	r := testRuntime(t, Config{},
+		mustDep(NewValueDep(&suppliedConfig{Addr: "localhost"})),
+		mustDep(NewFuncDep[edgeFoo](func(cfg *suppliedConfig) (edgeFoo, error) {
+			return cfg, nil
+		})),
+	)
+
+	// Not constructed.
+	infos := r.Describe()
+	if len(infos) != 2 {
+		t.Fatalf("Describe() = %v, want 2 deps", infos)
+	}
+
+	if _, err := r.GetIntf(Type[edgeFoo](), ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range r.Describe() {
+		if !info.Constructed {
+			t.Errorf("%s not constructed", info.Name)
+		}
+	}
+}
+
+func TestDescribeDecorators(t *testing.T) {
+	r := testRuntime(t, Config{},
+		mustDep(NewDep[decoGreeter, decoHello]()),
+		mustDep(NewDep[decoGreeter, decoHi]()),
+		mustDep(NewDecoratorDep[decoGreeter, decoShout](WithTarget("hi"))),
+	)
+	id := typeName(Type[decoGreeter]()) + "@" + typeName(Type[decoShout]()) + "$hi"
+	hi := typeName(Type[decoGreeter]()) + "$hi"
+	describe := func() DepInfo {
+		t.Helper()
+		infos := r.Describe()
+		i := slices.IndexFunc(infos, func(info DepInfo) bool { return info.ID == id })
+		if i < 0 {
+			t.Fatalf("decorator %q not described in %v", id, infos)
+		}
+		return infos[i]
+	}
+
+	info := describe()
+	if !info.Decorator || info.Constructed || info.Timing != nil {
+		t.Errorf("decorator %+v, want a decorator not constructed", info)
+	}
+	if want := []string{hi}; !slices.Equal(info.Refs, want) {
+		t.Errorf("refs %v, want %v", info.Refs, want)
+	}
+
+	if _, err := r.GetIntf(Type[decoGreeter](), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	info = describe()
+	if !info.Constructed || info.Timing == nil {
+		t.Errorf("decorator %+v, want constructed", info)
+	}
+	if want := []string{hi}; !slices.Equal(info.Refs, want) {
+		t.Errorf("refs %v, want %v", info.Refs, want)
+	}
+}
+
+type debugConfig struct {
+	User     string
+	Password string
+	APIToken string `toml:"api_token"`
+	DSN      string `deps:"secret"`
+	Headers  map[string]string
+	Nested   struct {
+		PrivateKey string `toml:"private_key"`
+		Region     string
+	}
+}
+
+type debugIface interface{}
+
+type debugImpl struct {
+	Implements[debugIface]
+	WithConfig[debugConfig] `section:"debug"`
+}
+
+func TestDebugHandlerRedacts(t *testing.T) {
+	r := testRuntime(t, Config{Config: `
+[debug]
+user = "alice"
+password = "hunter2"
+api_token = "tok-123"
+dsn = "postgres://alice:pw@db"
+
+[debug.headers]
+accept = "json"
+auth_token = "bearer-xyz"
+
+[debug.nested]
+private_key = "pem-abc"
+region = "eu"
+`}, mustDep(NewDep[debugIface, debugImpl]()))
+	if _, err := r.GetIntf(Type[debugIface](), ""); err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{"hunter2", "tok-123", "postgres://alice:pw@db", "bearer-xyz", "pem-abc"}
+
+	for _, tc := range []struct {
+		name   string
+		target string
+		accept string
+		ctype  string
+	}{
+		{"html", "/debug/deps", "", "text/html; charset=utf-8"},
+		{"json query", "/debug/deps?format=json", "", "application/json"},
+		{"json accept", "/debug/deps", "application/json", "application/json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			DebugHandler(r).ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d, want 200", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tc.ctype {
+				t.Errorf("content type %q, want %q", ct, tc.ctype)
+			}
+			body := w.Body.String()
+			for _, secret := range secrets {
+				if strings.Contains(body, secret) {
+					t.Errorf("the output contains the secret %q:\n%s", secret, body)
+				}
+			}
+			for _, public := range []string{"alice", "eu", "REDACTED"} {
+				if !strings.Contains(body, public) {
+					t.Errorf("the output doesn't contain %q:\n%s", public, body)
+				}
+			}
+			if tc.ctype != "application/json" {
+				return
+			}
+
+			var state struct {
+				Deps []struct {
+					Config map[string]any
+				}
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+				t.Fatal(err)
+			}
+			if len(state.Deps) != 1 {
+				t.Fatalf("%d deps, want 1", len(state.Deps))
+			}
+			want := map[string]any{
+				"User":      "alice",
+				"Password":  "REDACTED",
+				"api_token": "REDACTED",
+				"DSN":       "REDACTED",
+				"Headers":   map[string]any{"accept": "json", "auth_token": "REDACTED"},
+				"Nested":    map[string]any{"private_key": "REDACTED", "Region": "eu"},
+			}
+			if got := state.Deps[0].Config; !reflect.DeepEqual(got, want) {
+				t.Errorf("config %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// NewDecoratorDep returns the Dep of the decorator Impl of Iface. See
+// Decorate.
+func NewDecoratorDep[Iface any, Impl any](opts ...Option) (Dep, error) {
+	iface, err := IfaceType[Iface]()
+	if err != nil {
+		return Dep{}, err
+	}
+	impl, err := StructType[Impl]()
+	if err != nil {
+		return Dep{}, err
+	}
+	if _, ok := impl.FieldByName("xxx_ifaceType"); !ok {
+		return Dep{}, errors.New("implementation does not embed deps.Implements")
+	}
+	inner := false
+	for _, ref := range refFields(impl) {
+		if ref.typ == iface {
+			inner = true
+		}
+	}
+	if !inner {
+		return Dep{}, fmt.Errorf("decorator %v has no deps.Ref[%v] field to the decorated implementation", impl, iface)
+	}
+
+	dep := Dep{iface: iface, impl: impl, decorator: true}
+	for _, o := range opts {
+		o(&dep)
+	}
+	if dep.name == "" {
+		dep.name = typeName(impl)
+	}
+	if dep.id == "" {
+		dep.id = typeName(iface) + "@" + typeName(impl)
+		if dep.target != "" {
+			dep.id += "$" + dep.target
+		}
+	}
+	return dep, nil
+}
+
+// Decorate registers Impl as a decorator of the implementations of Iface.
+// Impl embeds deps.Implements[Iface] and has a deps.Ref[Iface] field, which
+// is set to the decorated implementation rather than resolved:
+//
+//	type cachingFoo struct {
+//		deps.Implements[Foo]
+//		inner deps.Ref[Foo]
+//	}
+//
+//	deps.MustDecorate[Foo, cachingFoo]()
+//
+// The consumers of Foo, and GetIntf, get the decorated instance, while
+// GetImpl still returns the undecorated implementation. The decorators of
+// an interface are applied in registration order, the first one wrapping
+// the implementation and the last one being handed to the consumers. By
+// default a decorator applies to every implementation of Iface; WithTarget
+// restricts it to the implementation with the given name.
+//
+// The decorated instance has the lifetime of the implementation. Decorators
+// are skipped for the implementations faked with Config.Fakes or
+// Config.NamedFakes.
+func Decorate[Iface any, Impl any](opts ...Option) error {
+	dep, err := NewDecoratorDep[Iface, Impl](opts...)
+	if err != nil {
+		return err
+	}
+	return globalRegistry.register(dep)
+}
+
+// MustDecorate is like Decorate but panics on error.
+func MustDecorate[Iface any, Impl any](opts ...Option) {
+	if err := Decorate[Iface, Impl](opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Decorates is the module counterpart of Decorate.
+func Decorates[Iface any, Impl any](opts ...Option) ModuleItem {
+	dep, err := NewDecoratorDep[Iface, Impl](opts...)
+	return registration{dep, err}
+}
+
+// WithTarget restricts a decorator to the implementation with the given
+// name. It has no effect on the other deps.
+func WithTarget(name string) Option {
+	return func(dep *Dep) {
+		dep.target = name
+	}
+}
+
+// decorates returns true if the decorator d applies to dep.
+func (d *Dep) decorates(dep *Dep) bool {
+	return d.iface == dep.iface && (d.target == "" || d.target == dep.name)
+}
+
+// splitDecorators separates the decorators from the other deps, and returns
+// the decorators by interface, in registration order.
+func splitDecorators(deps []*Dep) ([]*Dep, map[reflect.Type][]*Dep) {
+	var others []*Dep
+	decorators := map[reflect.Type][]*Dep{}
+	for _, dep := range deps {
+		if dep.decorator {
+			decorators[dep.iface] = append(decorators[dep.iface], dep)
+		} else {
+			others = append(others, dep)
+		}
+	}
+	return others, decorators
+}
+
+// validateDecorators checks that the decorators decorate registered
+// implementations.
+func validateDecorators(decorators map[reflect.Type][]*Dep, depsByIntf map[reflect.Type]map[string]*Dep) error {
+	var errs []error
+	for iface, ds := range decorators {
+		for _, d := range ds {
+			deps, ok := depsByIntf[iface]
+			if !ok {
+				errs = append(errs, fmt.Errorf("decorator %q decorates %v, but %v was not registered", d.label(), iface, iface))
+				continue
+			}
+			if d.target == "" {
+				continue
+			}
+			if _, ok := deps[d.target]; !ok {
+				errs = append(errs, fmt.Errorf("decorator %q decorates %v named %q, but it was not registered", d.label(), iface, d.target))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// decoratorsOf returns the decorators which apply to dep, innermost first.
+func (r *runtime) decoratorsOf(dep *Dep) []*Dep {
+	var ds []*Dep
+	for _, d := range r.decorators[dep.iface] {
+		if d.decorates(dep) {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// getDecoratedLocked is like getDecorated, but locks the runtime.
+func (r *runtime) getDecoratedLocked(dep *Dep) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.getDecorated(dep)
+}
+
+// getDecorated returns the instance of dep wrapped by its decorators.
+func (r *runtime) getDecorated(dep *Dep) (any, error) {
+	decorators := r.decoratorsOf(dep)
+	if len(decorators) == 0 {
+		return r.get(dep)
+	}
+	if _, ok := r.fake(dep); ok {
+		return r.get(dep)
+	}
+	if dep.lifetime == Singleton && r.parent != nil && !r.owns(dep) {
+		return r.parent.getDecoratedLocked(dep)
+	}
+	if v, ok := r.decorated[dep.id]; ok {
+		return v, nil
+	}
+
+	obj, err := r.get(dep)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range decorators {
+		if obj, err = r.build(d, obj, dep.lifetime); err != nil {
+			return nil, fmt.Errorf("decorating %q: %w", dep.label(), err)
+		}
+	}
+	if dep.lifetime != Transient {
+		r.decorated[dep.id] = obj
+	}
+	return obj, nil
+}
@@ -0,0 +1,114 @@
+package deps
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type decoGreeter interface {
+	Greet() string
+}
+
+type decoHello struct {
+	Implements[decoGreeter] `impl:"hello"`
+}
+
+func (g *decoHello) Greet() string { return "hello" }
+
+type decoHi struct {
+	Implements[decoGreeter] `impl:"hi"`
+}
+
+func (g *decoHi) Greet() string { return "hi" }
+
+type decoBrackets struct {
+	Implements[decoGreeter]
+	inner Ref[decoGreeter]
+}
+
+func (g *decoBrackets) Greet() string { return "[" + g.inner.Get().Greet() + "]" }
+
+type decoShout struct {
+	Implements[decoGreeter]
+	inner Ref[decoGreeter]
+}
+
+func (g *decoShout) Greet() string { return strings.ToUpper(g.inner.Get().Greet()) + "!" }
+
+func TestDecorators(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		decorators []*Dep
+		hello, hi  string
+	}{
+		{
+			name:  "none",
+			hello: "hello",
+			hi:    "hi",
+		},
+		{
+			name: "registration order",
+			decorators: []*Dep{
+				mustDep(NewDecoratorDep[decoGreeter, decoBrackets]()),
+				mustDep(NewDecoratorDep[decoGreeter, decoShout]()),
+			},
+			hello: "[HELLO]!",
+			hi:    "[HI]!",
+		},
+		{
+			name: "reverse order",
+			decorators: []*Dep{
+				mustDep(NewDecoratorDep[decoGreeter, decoShout]()),
+				mustDep(NewDecoratorDep[decoGreeter, decoBrackets]()),
+			},
+			hello: "[HELLO!]",
+			hi:    "[HI!]",
+		},
+		{
+			name: "target",
+			decorators: []*Dep{
+				mustDep(NewDecoratorDep[decoGreeter, decoBrackets]()),
+				mustDep(NewDecoratorDep[decoGreeter, decoShout](WithTarget("hi"))),
+			},
+			hello: "[hello]",
+			hi:    "[HI]!",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deps := []*Dep{
+				mustDep(NewDep[decoGreeter, decoHello]()),
+				mustDep(NewDep[decoGreeter, decoHi]()),
+			}
+			r := testRuntime(t, Config{}, append(deps, tc.decorators...)...)
+			for name, want := range map[string]string{"hello": tc.hello, "hi": tc.hi} {
+				g, err := r.GetIntf(Type[decoGreeter](), name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := g.(decoGreeter).Greet(); got != want {
+					t.Errorf("%s: Greet() = %q, want %q", name, got, want)
+				}
+			}
+
+			// GetImpl returns the undecorated implementation.
+			impl, err := r.GetImpl(Type[decoHello]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := impl.(*decoHello).Greet(); got != "hello" {
+				t.Errorf("GetImpl: Greet() = %q, want hello", got)
+			}
+		})
+	}
+}
+
+func TestDecoratorTargetNotRegistered(t *testing.T) {
+	_, err := newRuntime(context.Background(), []*Dep{
+		mustDep(NewDep[decoGreeter, decoHello]()),
+		mustDep(NewDecoratorDep[decoGreeter, decoShout](WithTarget("bye"))),
+	}, Config{})
+	if err == nil || !strings.Contains(err.Error(), `named "bye", but it was not registered`) {
+		t.Errorf("newRuntime() = %v, want the target error", err)
+	}
+}
@@ -15,13 +15,32 @@ type Dep struct {
 	name string
 	// interface type for the implementation
 	iface reflect.Type
-	// implementation type (struct)
+	// implementation type (struct), nil for a provider
 	impl reflect.Type
+	// constructor function, see ProvideFunc
+	provider reflect.Value
+	// supplied value, see Supply
+	value reflect.Value
 
 	// indicates the Iface is singleton
 	singleton bool
+	// lifetime of the instances
+	lifetime Lifetime
+	// config section, overriding the section tag
+	section string
+	// name of the module defining the dep, if any
+	module string
+	// condition and profiles of a conditional registration
+	condition func(ConfigView) bool
+	profiles  []string
 	// Functions that return different types of stubs.
 	hook func(impl any, caller string) any
+	// restart policy of the background service, if any
+	restart *RestartPolicy
+	// indicates the dep is a decorator, see Decorate
+	decorator bool
+	// name of the decorated implementation, empty for all of them
+	target string
 }
 
 // Option is used to setup the Dep.
@@ -51,27 +70,117 @@ func NewDep[Iface any, Impl any](opts ...Option) (Dep, error) {
 		// name := f.Tag.Get("impl")
 		f := dep.impl.Field(i)
 		switch {
-		case f.Type.Implements(Type[getRuntime]()):
+		case f.Type.Implements(Type[getRuntime]()) && dep.name == "":
 			dep.name = f.Tag.Get("impl")
 		}
 	}
 
-	fullname := dep.iface.PkgPath() + "." + dep.iface.Name()
+	dep.initNames()
+	return dep, nil
+}
+
+// initNames sets the default name and id of the dep.
+func (d *Dep) initNames() {
+	fullname := typeName(d.iface)
 
 	// Anonymous dependency
-	if dep.name == "" {
-		dep.name = fullname
+	if d.name == "" {
+		d.name = fullname
 	}
 
-	if dep.id == "" {
-		if dep.name == fullname {
-			dep.id = fullname
+	if d.id == "" {
+		if d.name == fullname {
+			d.id = fullname
 		} else {
-			dep.id = fullname + "$" + dep.name
+			d.id = fullname + "$" + d.name
 		}
 	}
+}
 
-	return dep, nil
+// Name returns the name of the dep, used by the ref tags to refer to it.
+func (d *Dep) Name() string {
+	return d.name
+}
+
+// Iface returns the interface type of the dep.
+func (d *Dep) Iface() reflect.Type {
+	return d.iface
+}
+
+// Hook returns the hook function of the dep, set by WithHook, or nil.
+func (d *Dep) Hook() func(impl any, caller string) any {
+	return d.hook
+}
+
+// Anonymous returns true if the dep has no name of its own, and so is
+// referred to by the refs without tag.
+func (d *Dep) Anonymous() bool {
+	return d.anonymous()
+}
+
+// With returns a copy of the dep with the given options applied, like
+// WithHook. The options changing the name or the id of the dep have no
+// effect.
+func (d *Dep) With(opts ...Option) *Dep {
+	dep := *d
+	for _, o := range opts {
+		o(&dep)
+	}
+	dep.name, dep.id = d.name, d.id
+	return &dep
+}
+
+// label returns the name of the dep, prefixed with its module if any, as
+// shown in the errors.
+func (d *Dep) label() string {
+	if d.module != "" {
+		return d.module + "/" + d.name
+	}
+	return d.name
+}
+
+// anonymous returns true if the dep has no name of its own, and so is
+// referred to by the refs without tag.
+func (d *Dep) anonymous() bool {
+	return d.name == typeName(d.iface)
+}
+
+// WithName sets the name of the Dep, used by the ref tags to refer to it,
+// instead of the impl tag of the deps.Implements field. It is needed by the
+// implementations which can't have a tag, like the generic ones or the ones
+// provided by constructor functions.
+func WithName(name string) Option {
+	return func(dep *Dep) {
+		dep.name = name
+	}
+}
+
+// WithSection sets the config section of the Dep, instead of the section
+// tag of the deps.WithConfig field. Together with WithName, it allows to
+// register the same implementation several times with different configs:
+//
+//	deps.MustProvide[Cache, redisCache](deps.WithName("cacheA"), deps.WithSection("cacheA"))
+//	deps.MustProvide[Cache, redisCache](deps.WithName("cacheB"), deps.WithSection("cacheB"))
+func WithSection(section string) Option {
+	return func(dep *Dep) {
+		dep.section = section
+	}
+}
+
+// WithID sets the unique id of the Dep, instead of deriving it from the
+// interface and the name.
+func WithID(id string) Option {
+	return func(dep *Dep) {
+		dep.id = id
+	}
+}
+
+// WithSingleton marks the interface of the Dep as single-implementation:
+// registering another implementation of the interface fails.
+func WithSingleton() Option {
+	return func(dep *Dep) {
+		dep.singleton = true
+	}
 }
 
 // WithHook sets the hook function for the Dep.
@@ -80,3 +189,15 @@ func WithHook(hook func(impl any, caller string) any) Option {
 		dep.hook = hook
 	}
 }
+
+// implType returns the implementation type, the type of the constructor
+// function for a provider or the type of the supplied value.
+func (d *Dep) implType() reflect.Type {
+	switch {
+	case d.provider.IsValid():
+		return d.provider.Type()
+	case d.value.IsValid():
+		return d.value.Type()
+	}
+	return d.impl
+}
@@ -0,0 +1,110 @@
+package deps
+
+import (
+	"testing"
+)
+
+type optIface interface{}
+
+type optConfig struct {
+	Addr string
+}
+
+type optImpl struct {
+	Implements[optIface]  `impl:"tagged"`
+	WithConfig[optConfig] `section:"tagsection"`
+}
+
+func TestDepOptions(t *testing.T) {
+	prefix := typeName(Type[optIface]()) + "$"
+	for _, tc := range []struct {
+		name    string
+		opts    []Option
+		depName string
+		id      string
+		addr    string
+	}{
+		{
+			name:    "tags",
+			depName: "tagged",
+			id:      prefix + "tagged",
+			addr:    "tagsection",
+		},
+		{
+			name:    "with name",
+			opts:    []Option{WithName("renamed")},
+			depName: "renamed",
+			id:      prefix + "renamed",
+			addr:    "tagsection",
+		},
+		{
+			name:    "with id",
+			opts:    []Option{WithID("custom")},
+			depName: "tagged",
+			id:      "custom",
+			addr:    "tagsection",
+		},
+		{
+			name:    "with section",
+			opts:    []Option{WithSection("other")},
+			depName: "tagged",
+			id:      prefix + "tagged",
+			addr:    "other",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := mustDep(NewDep[optIface, optImpl](tc.opts...))
+			if dep.Name() != tc.depName || dep.id != tc.id {
+				t.Errorf("name %q, id %q; want %q, %q", dep.Name(), dep.id, tc.depName, tc.id)
+			}
+
+			r := testRuntime(t, Config{Config: `
+[tagsection]
+addr = "tagsection"
+
+[other]
+addr = "other"
+`}, dep)
+			v, err := r.GetIntf(Type[optIface](), tc.depName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.(*optImpl).Config().Addr; got != tc.addr {
+				t.Errorf("config from %q, want %q", got, tc.addr)
+			}
+			if _, ok := r.impls[tc.id]; !ok {
+				t.Errorf("instance not constructed with id %q", tc.id)
+			}
+			if tc.depName != "tagged" {
+				// The impl tag no longer names the dep.
+				if _, err := r.GetIntf(Type[optIface](), "tagged"); err == nil {
+					t.Error("GetIntf(tagged) succeeded, want an error")
+				}
+			}
+		})
+	}
+}
+
+func TestWithHook(t *testing.T) {
+	tag := func(s string) func(any, string) any {
+		return func(impl any, caller string) any { return s + " for " + caller }
+	}
+	dep := mustDep(NewFuncDep[optIface](func() optIface { return "impl" }, WithHook(tag("first"))))
+	// The last hook replaces the previous ones.
+	dep = dep.With(WithHook(tag("second")))
+	if got := dep.Hook()("impl", "caller"); got != "second for caller" {
+		t.Errorf("Hook() returned %v, want the second hook", got)
+	}
+
+	r := testRuntime(t, Config{}, dep)
+	v, err := r.GetIntf(Type[optIface](), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "second for root" {
+		t.Errorf("GetIntf() = %v, want the instance of the second hook", v)
+	}
+	if dep := mustDep(NewFuncDep[optIface](func() optIface { return "impl" })); dep.Hook() != nil {
+		t.Error("Hook() is not nil without WithHook")
+	}
+}
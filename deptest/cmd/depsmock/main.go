@@ -0,0 +1,292 @@
+// Depsmock generates recording mocks of component interfaces, backed by
+// deptest.Mock. Run it in the directory of the package defining the
+// interface, typically with go generate:
+//
+//	//go:generate go run github.com/cgfork/deps/deptest/cmd/depsmock -type Foo
+//
+// It writes the MockFoo type and the NewMockFoo function to foo_mock_test.go.
+// The methods of the interfaces embedded in Foo, of any package, are mocked
+// too. The imported packages are loaded with go list.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	typeName = flag.String("type", "", "name of the interface to mock")
+	dir      = flag.String("dir", ".", "directory of the package defining the interface")
+	output   = flag.String("out", "", "output file name; default <type>_mock_test.go")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("depsmock: ")
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := generate(*dir, *typeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := *output
+	if out == "" {
+		out = strings.ToLower(*typeName) + "_mock_test.go"
+	}
+	if err := os.WriteFile(filepath.Join(*dir, out), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parsePackage parses and type-checks the package in dir. The imported
+// packages are loaded from their export data, built by go list.
+func parsePackage(dir string) (*types.Package, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	paths := map[string]bool{}
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			paths[path] = true
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	exports, err := exportData(dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+			file, ok := exports[path]
+			if !ok {
+				return nil, fmt.Errorf("no export data for %s", path)
+			}
+			return os.Open(file)
+		}),
+	}
+	return conf.Check(files[0].Name.Name, fset, files, nil)
+}
+
+// exportData returns the export data files of the packages with the given
+// import paths and of their dependencies, by import path.
+func exportData(dir string, paths map[string]bool) (map[string]string, error) {
+	delete(paths, "C")
+	exports := map[string]string{}
+	if len(paths) == 0 {
+		return exports, nil
+	}
+	args := []string{"list", "-export", "-deps", "-f", "{{.ImportPath}}\t{{.Export}}"}
+	for path := range paths {
+		args = append(args, path)
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v\n%s", err, stderr.Bytes())
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		path, file, _ := strings.Cut(sc.Text(), "\t")
+		if file != "" {
+			exports[path] = file
+		}
+	}
+	return exports, sc.Err()
+}
+
+// lookup returns the interface with the given name.
+func lookup(pkg *types.Package, name string) (*types.Interface, error) {
+	obj := pkg.Scope().Lookup(name)
+	if obj == nil {
+		return nil, fmt.Errorf("interface %s not found", name)
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a type", name)
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a defined type", name)
+	}
+	if named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("generic interface %s is not supported", name)
+	}
+	it, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface", name)
+	}
+	return it, nil
+}
+
+// imports are the imports of the generated file.
+type imports struct {
+	pkg    *types.Package    // the package of the generated file
+	names  map[string]string // import path to name
+	byName map[string]string // name to import path
+}
+
+func newImports(pkg *types.Package) *imports {
+	im := &imports{pkg: pkg, names: map[string]string{}, byName: map[string]string{}}
+	im.add("testing", "testing")
+	im.add("github.com/cgfork/deps/deptest", "deptest")
+	return im
+}
+
+// add imports the package with the given path and name, renamed if the name
+// is taken, and returns the name to qualify its identifiers with.
+func (im *imports) add(path, name string) string {
+	if n, ok := im.names[path]; ok {
+		return n
+	}
+	n := name
+	for i := 2; im.byName[n] != "" || n == im.pkg.Name(); i++ {
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+	im.names[path] = n
+	im.byName[n] = path
+	return n
+}
+
+// qualifier qualifies the identifiers of the other packages, importing them.
+func (im *imports) qualifier(p *types.Package) string {
+	if p == im.pkg {
+		return ""
+	}
+	return im.add(p.Path(), p.Name())
+}
+
+// write writes the import declaration, the standard library first.
+func (im *imports) write(buf *bytes.Buffer) {
+	var std, others []string
+	for path := range im.names {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	buf.WriteString("import (\n")
+	for i, paths := range [][]string{std, others} {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		for _, path := range paths {
+			// The name is explicit unless it is the last element of the path.
+			if n := im.names[path]; n == filepath.Base(path) {
+				fmt.Fprintf(buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(buf, "\t%s %q\n", n, path)
+			}
+		}
+	}
+	buf.WriteString(")\n\n")
+}
+
+func generate(dir, name string) ([]byte, error) {
+	pkg, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	it, err := lookup(pkg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	im := newImports(pkg)
+	typ := func(t types.Type) string { return types.TypeString(t, im.qualifier) }
+	var body bytes.Buffer
+	mock := "Mock" + name
+	fmt.Fprintf(&body, "// %s is a recording mock of %s.\n", mock, name)
+	fmt.Fprintf(&body, "type %s struct {\n\tMock *deptest.Mock\n}\n\n", mock)
+	fmt.Fprintf(&body, "// New%s returns a %s asserting its expectations when t ends.\n", mock, mock)
+	fmt.Fprintf(&body, "func New%s(t testing.TB) *%s {\n\treturn &%s{Mock: deptest.NewMock(t)}\n}\n\n", mock, mock, mock)
+	fmt.Fprintf(&body, "// DepsMock implements deptest.Mocker.\n")
+	fmt.Fprintf(&body, "func (m *%s) DepsMock() *deptest.Mock {\n\treturn m.Mock\n}\n", mock)
+
+	// The methods include the ones of the embedded interfaces, of any
+	// package, and are sorted by name.
+	methods := make([]*types.Func, it.NumMethods())
+	for i := range methods {
+		methods[i] = it.Method(i)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+	for _, m := range methods {
+		if !m.Exported() && m.Pkg() != pkg {
+			return nil, fmt.Errorf("interface %s: unexported method %s of package %s can't be implemented", name, m.Name(), m.Pkg().Path())
+		}
+		sig := m.Type().(*types.Signature)
+		var params, args []string
+		for i := 0; i < sig.Params().Len(); i++ {
+			arg := fmt.Sprintf("a%d", i)
+			t := typ(sig.Params().At(i).Type())
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				t = "..." + typ(sig.Params().At(i).Type().(*types.Slice).Elem())
+			}
+			params = append(params, arg+" "+t)
+			args = append(args, arg)
+		}
+		var results []string
+		for i := 0; i < sig.Results().Len(); i++ {
+			results = append(results, typ(sig.Results().At(i).Type()))
+		}
+
+		call := fmt.Sprintf("m.Mock.Called(%s)", strings.Join(append([]string{strconv.Quote(m.Name())}, args...), ", "))
+		fmt.Fprintf(&body, "\nfunc (m *%s) %s(%s) ", mock, m.Name(), strings.Join(params, ", "))
+		switch len(results) {
+		case 0:
+			fmt.Fprintf(&body, "{\n\t%s\n}\n", call)
+		default:
+			rets := make([]string, len(results))
+			for i, r := range results {
+				rets[i] = fmt.Sprintf("deptest.Ret[%s](r, %d)", r, i)
+			}
+			fmt.Fprintf(&body, "(%s) {\n\tr := %s\n\treturn %s\n}\n", strings.Join(results, ", "), call, strings.Join(rets, ", "))
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by depsmock. DO NOT EDIT.\n\npackage %s\n\n", pkg.Name())
+	im.write(&buf)
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
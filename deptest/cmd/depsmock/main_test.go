@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// testModule copies the package of testdata/name to a module requiring this
+// one, and returns its directory.
+func testModule(t *testing.T, name string) string {
+	t.Helper()
+	root, err := filepath.Abs("../../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files, err := filepath.Glob(filepath.Join("testdata", name, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		copyFile(t, file, filepath.Join(dir, filepath.Base(file)))
+	}
+	copyFile(t, filepath.Join(root, "go.sum"), filepath.Join(dir, "go.sum"))
+	mod := `module example.com/` + name + `
+
+go 1.22
+
+require (
+	github.com/BurntSushi/toml v1.3.2 // indirect
+	github.com/cgfork/deps v0.0.0
+)
+
+replace github.com/cgfork/deps => ` + root + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	b, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	for _, tc := range []struct {
+		pkg, typ string
+	}{
+		{"versioned", "Shuffler"},
+		{"embedded", "Store"},
+	} {
+		t.Run(tc.pkg, func(t *testing.T) {
+			dir := testModule(t, tc.pkg)
+			got, err := generate(dir, tc.typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tc.pkg, strings.ToLower(tc.typ)+"_mock_test.go.golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated:\n%s\nwant:\n%s", got, want)
+			}
+
+			// The generated mock must compile.
+			if err := os.WriteFile(filepath.Join(dir, strings.ToLower(tc.typ)+"_mock_test.go"), got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command("go", "vet", ".")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("go vet: %v\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	dir := testModule(t, "embedded")
+	for typ, want := range map[string]string{
+		"Missing": "interface Missing not found",
+		"Item":    "Item is not an interface",
+	} {
+		if _, err := generate(dir, typ); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("generate(%q) = %v, want %q", typ, err, want)
+		}
+	}
+}
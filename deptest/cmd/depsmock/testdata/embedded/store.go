@@ -0,0 +1,25 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+type Item struct {
+	Key   string
+	Value []byte
+}
+
+type Getter interface {
+	Get(ctx context.Context, key string) (item *Item, ok bool, err error)
+}
+
+// Store embeds interfaces of the package and of other packages.
+type Store interface {
+	io.Closer
+	fmt.Stringer
+	Getter
+	Put(ctx context.Context, items ...Item) error
+	Watch(func(key string)) <-chan map[string]any
+}
@@ -0,0 +1,8 @@
+package versioned
+
+import "math/rand"
+
+// Seeder takes a package with the same name as the one of Shuffler.
+type Seeder interface {
+	Seed(src rand.Source)
+}
@@ -0,0 +1,9 @@
+package versioned
+
+import "math/rand/v2"
+
+// Shuffler takes a package whose name isn't the last element of its path.
+type Shuffler interface {
+	Shuffle(r *rand.Rand, n int) []int
+	Seeder
+}
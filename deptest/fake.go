@@ -0,0 +1,16 @@
+package deptest
+
+import "github.com/cgfork/deps"
+
+// Fake returns a fake replacing the implementation of T with the given name,
+// to be added to deps.Config.NamedFakes. An empty name fakes the anonymous
+// implementation.
+//
+//	deptest.Test(t, deps.Config{
+//		NamedFakes: []deps.Fake{deptest.Fake[Foo]("fooA", &fakeFoo{})},
+//	}, func(t *testing.T, bar Bar) {
+//		// bar gets the fake fooA, and the real fooB.
+//	})
+func Fake[T any](name string, impl T) deps.Fake {
+	return deps.Fake{Iface: deps.Type[T](), Name: name, Impl: impl}
+}
@@ -0,0 +1,125 @@
+package deptest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cgfork/deps"
+)
+
+// Fixture is a component graph built once, and shared by several test cases
+// or the inputs of a fuzz target. The components chosen with Reset are
+// rebuilt for every case.
+//
+//	func TestFoo(t *testing.T) {
+//		fx := deptest.New(t, config, deptest.Reset[Cache]())
+//		for _, tc := range cases {
+//			fx.Run(t, tc.name, func(t *testing.T, foo Foo) {
+//				// Testing code
+//			})
+//		}
+//	}
+type Fixture struct {
+	runner deps.Runtime
+	config deps.Config
+	o      *options
+	reset  []*deps.Dep
+}
+
+// New returns a fixture, shut down when t ends. The options apply to all
+// the cases, except Parallel which applies to the cases run by Run.
+func New(t testing.TB, config deps.Config, opts ...Option) *Fixture {
+	t.Helper()
+	o := newOptions(opts)
+	runner := start(t, config, o)
+	f := &Fixture{runner: runner, config: config, o: o}
+	// The deps of the runtime include the ones of the modules.
+	for _, dep := range runner.(deps.Parent).Deps() {
+		for _, r := range o.resets {
+			if dep.Iface() == r.iface && r.matches(dep) {
+				f.reset = append(f.reset, dep)
+			}
+		}
+	}
+	return f
+}
+
+// runtime returns the runtime of a case: a child runtime rebuilding the
+// reset components, shut down when t ends.
+func (f *Fixture) runtime(t testing.TB) deps.Runtime {
+	t.Helper()
+	if len(f.reset) == 0 {
+		return f.runner
+	}
+	child, err := f.runner.(deps.Parent).Child(deps.Overrides{Deps: f.reset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shutdown(t, child, f.config) })
+	return child
+}
+
+// Run runs body as a sub-test of t with the given name. body is a function
+// like the one of Test.
+func (f *Fixture) Run(t *testing.T, name string, body any) bool {
+	t.Helper()
+	call, _, err := checkRunFunc(t, body, f.o)
+	if err != nil {
+		t.Fatal(fmt.Errorf("deptest.Fixture.Run argument: %v", err))
+	}
+	return t.Run(name, func(t *testing.T) {
+		if f.o.parallel {
+			t.Parallel()
+		}
+		invoke, err := call(f.runtime(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		invoke(t)
+	})
+}
+
+// Fuzz runs the fuzz target body with f. body is a function like:
+//
+//	func(t *testing.T, foo Foo, bar *bar, data []byte, n int)
+//
+// whose component arguments are followed by the fuzzing arguments.
+func (f *Fixture) Fuzz(fz *testing.F, body any) {
+	fz.Helper()
+	fn := reflect.ValueOf(body)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func || fnType.IsVariadic() || fnType.NumOut() > 0 ||
+		fnType.NumIn() < 1 || fnType.In(0) != reflect.TypeOf(&testing.T{}) {
+		fz.Fatalf("deptest.Fixture.Fuzz argument: %v is not a func(*testing.T, ...)", fnType)
+	}
+	// The component arguments are the ones before the first fuzzing one.
+	n := 1
+	for n < fnType.NumIn() && isComponentArg(fnType.In(n)) {
+		n++
+	}
+	if _, err := checkComponentArgs(fnType, n, f.o); err != nil {
+		fz.Fatal(fmt.Errorf("deptest.Fixture.Fuzz argument: %v", err))
+	}
+
+	in := []reflect.Type{fnType.In(0)}
+	for i := n; i < fnType.NumIn(); i++ {
+		in = append(in, fnType.In(i))
+	}
+	target := reflect.MakeFunc(reflect.FuncOf(in, nil, false), func(args []reflect.Value) []reflect.Value {
+		t := args[0].Interface().(*testing.T)
+		comps, err := getComponents(f.runtime(t), fnType, n, f.o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all := append([]reflect.Value{args[0]}, comps...)
+		fn.Call(append(all, args[1:]...))
+		return nil
+	})
+	fz.Fuzz(target.Interface())
+}
+
+// isComponentArg returns true if t is the type of a component argument.
+func isComponentArg(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface || t.Kind() == reflect.Pointer
+}
@@ -0,0 +1,94 @@
+package deptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cgfork/deps"
+)
+
+type counter interface {
+	Next() int
+}
+
+type counterImpl struct {
+	deps.Implements[counter]
+	n int
+}
+
+func (c *counterImpl) Next() int {
+	c.n++
+	return c.n
+}
+
+func TestFixtureReset(t *testing.T) {
+	module := deps.Module("counting", deps.Provides[counter, counterImpl]())
+	for _, tc := range []struct {
+		name   string
+		config deps.Config
+		opts   []Option
+		want   []int // the first value of the counter in every case
+	}{
+		{
+			name: "shared",
+			opts: []Option{WithRegistry(newRegistry(t, deps.Provides[counter, counterImpl]()))},
+			want: []int{1, 2, 3},
+		},
+		{
+			name: "reset",
+			opts: []Option{WithRegistry(newRegistry(t, deps.Provides[counter, counterImpl]())), Reset[counter]()},
+			want: []int{1, 1, 1},
+		},
+		{
+			name: "reset anonymous",
+			opts: []Option{WithRegistry(newRegistry(t, deps.Provides[counter, counterImpl]())), Reset[counter]("")},
+			want: []int{1, 1, 1},
+		},
+		{
+			name:   "reset module",
+			config: deps.Config{Modules: []*deps.ModuleDef{module}},
+			opts:   []Option{WithRegistry(newRegistry(t)), Reset[counter]()},
+			want:   []int{1, 1, 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fx := New(t, tc.config, tc.opts...)
+			for i, want := range tc.want {
+				fx.Run(t, fmt.Sprint(i), func(t *testing.T, c counter) {
+					if got := c.Next(); got != want {
+						t.Errorf("Next() = %d, want %d", got, want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func FuzzFixture(f *testing.F) {
+	reg := newRegistry(f, deps.Provides[counter, counterImpl](), deps.Provides[greeter, hello]())
+	fx := New(f, deps.Config{}, WithRegistry(reg), Reset[counter]())
+	for _, n := range []uint8{3, 0, 5} {
+		f.Add(n)
+	}
+	var shared *hello
+	fx.Fuzz(f, func(t *testing.T, c counter, h *hello, n uint8) {
+		// The greeter is shared by the inputs.
+		if shared == nil {
+			shared = h
+		}
+		if h != shared {
+			t.Errorf("hello %p, want the shared %p", h, shared)
+		}
+		if got, _ := h.Greet("you"); got != "hello you" {
+			t.Errorf("Greet() = %q, want hello you", got)
+		}
+
+		// The counter is rebuilt for every input.
+		for i := 0; i < int(n); i++ {
+			c.Next()
+		}
+		if got := c.Next(); got != int(n)+1 {
+			t.Errorf("Next() = %d after %d calls, want %d", got, n, n+1)
+		}
+	})
+}
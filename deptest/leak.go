@@ -0,0 +1,69 @@
+package deptest
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTimeout bounds the wait for the goroutines to exit.
+const leakTimeout = time.Second
+
+// checkLeaks returns a function failing t if goroutines other than the
+// current ones are running.
+func checkLeaks(t testing.TB) func() {
+	before := map[string]bool{}
+	for id := range goroutines() {
+		before[id] = true
+	}
+	return func() {
+		t.Helper()
+		var leaked []string
+		deadline := time.Now().Add(leakTimeout)
+		for {
+			leaked = leaked[:0]
+			for id, stack := range goroutines() {
+				if !before[id] && !strings.Contains(stack, "created by testing.") {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		for _, stack := range leaked {
+			t.Errorf("deptest: leaked goroutine:\n%s", stack)
+		}
+	}
+}
+
+// goroutines returns the stacks of the running goroutines, except the
+// current one, by goroutine id.
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := map[string]string{}
+	for i, stack := range strings.Split(string(buf), "\n\n") {
+		if i == 0 {
+			// The current goroutine.
+			continue
+		}
+		// The first line looks like "goroutine 42 [running]:".
+		header, _, _ := strings.Cut(stack, "\n")
+		fields := strings.Fields(header)
+		if len(fields) < 2 {
+			continue
+		}
+		stacks[fields[1]] = stack
+	}
+	return stacks
+}
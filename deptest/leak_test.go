@@ -0,0 +1,68 @@
+package deptest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cgfork/deps"
+)
+
+// leaky starts a goroutine in Init, stopped by Shutdown if tidy.
+type leaky struct {
+	deps.Implements[greeter] `impl:"leaky"`
+	stop                     chan struct{}
+}
+
+func (l *leaky) Init(context.Context) error {
+	l.stop = make(chan struct{})
+	go func() { <-l.stop }()
+	return nil
+}
+
+func (l *leaky) Greet(name string) (string, error) { return "leaky " + name, nil }
+
+type tidy struct {
+	leaky
+}
+
+func (t *tidy) Shutdown(context.Context) error {
+	close(t.stop)
+	return nil
+}
+
+func TestCheckLeaks(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		item   deps.ModuleItem
+		leaked bool
+	}{
+		{"leaked", deps.Provides[greeter, leaky](), true},
+		{"stopped", deps.Provides[greeter, tidy](deps.WithName("tidy")), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := newRegistry(t, tc.item)
+			ft := &fakeT{}
+			runner := start(ft, deps.Config{}, newOptions([]Option{CheckLeaks(), WithRegistry(reg)}))
+			dep := reg.Deps()[0]
+			g, err := runner.GetIntf(deps.Type[greeter](), dep.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := ft.finish()
+			if l, ok := g.(*leaky); ok {
+				close(l.stop)
+			}
+
+			if !tc.leaked {
+				if len(errs) != 0 {
+					t.Errorf("errors %q, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0], "deptest: leaked goroutine") || !strings.Contains(errs[0], "(*leaky).Init") {
+				t.Errorf("errors %q, want the goroutine started by Init", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package deptest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mock records the calls of a mock and returns their stubbed results. The
+// mocks of the component interfaces are generated by depsmock, and embed a
+// *Mock:
+//
+//	//go:generate go run github.com/cgfork/deps/deptest/cmd/depsmock -type Foo
+//
+//	func TestBar(t *testing.T) {
+//		foo := NewMockFoo(t)
+//		foo.Mock.On("Get").Return("value", nil).Times(1)
+//		deptest.Test(t, deps.Config{
+//			NamedFakes: []deps.Fake{deptest.Fake[Foo]("", foo)},
+//		}, func(t *testing.T, bar Bar) {
+//			// ...
+//		})
+//	}
+//
+// The calls without a matching expectation fail the test and return the
+// zero values. The expectations are asserted when the test ends.
+type Mock struct {
+	t testing.TB
+
+	mu     sync.Mutex
+	calls  []Call
+	exps   []*Expectation
+	target reflect.Value         // the forwarded implementation, see Recorded
+	replay map[string][]recorded // the calls to replay by method, see Recorded
+}
+
+// Call is a call recorded by a Mock.
+type Call struct {
+	Method string
+	Args   []any
+	// Results are the results of the forwarded calls.
+	Results []any
+}
+
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = fmt.Sprintf("%#v", arg)
+	}
+	return fmt.Sprintf("%s(%s)", c.Method, strings.Join(args, ", "))
+}
+
+// Expectation is an expected call of a Mock, created by Mock.On.
+type Expectation struct {
+	m       *Mock
+	method  string
+	args    []any // nil matches any args
+	results []any
+	times   int // expected number of calls, or anyTimes
+	maybe   bool
+	calls   int
+}
+
+// anyTimes is the Expectation.times of the expectations of at least one
+// call.
+const anyTimes = -1
+
+// NewMock returns a Mock asserting its expectations when t ends.
+func NewMock(t testing.TB) *Mock {
+	m := &Mock{t: t}
+	t.Cleanup(m.assert)
+	return m
+}
+
+// On adds an expectation of a call of the given method. If args are given,
+// only the calls with equal args match.
+func (m *Mock) On(method string, args ...any) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &Expectation{m: m, method: method, args: args, times: anyTimes}
+	m.exps = append(m.exps, e)
+	return e
+}
+
+// Return sets the results of the matching calls.
+func (e *Expectation) Return(results ...any) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+	e.results = results
+	return e
+}
+
+// Times sets the expected number of matching calls. Once reached, the
+// expectation doesn't match anymore. By default, at least one call is
+// expected.
+func (e *Expectation) Times(n int) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+	e.times = n
+	return e
+}
+
+// Never expects no matching call, like Times(0).
+func (e *Expectation) Never() *Expectation {
+	return e.Times(0)
+}
+
+// Maybe makes the expectation optional.
+func (e *Expectation) Maybe() *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+	e.maybe = true
+	return e
+}
+
+// matches returns true if the call matches the expectation, regardless of
+// the number of calls.
+func (e *Expectation) matches(method string, args []any) bool {
+	if e.method != method {
+		return false
+	}
+	return len(e.args) == 0 || reflect.DeepEqual(e.args, args)
+}
+
+// Called records a call of the method, and returns the results of the first
+// matching expectation whose number of calls is not reached. It is called by
+// the generated mocks. The test fails if no expectation matches.
+func (m *Mock) Called(method string, args ...any) []any {
+	m.t.Helper()
+	m.mu.Lock()
+	if m.target.IsValid() {
+		target := m.target
+		m.mu.Unlock()
+		results := forward(target, method, args)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.calls = append(m.calls, Call{method, args, results})
+		return results
+	}
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+	if m.replay != nil {
+		return m.replayed(method, args)
+	}
+	exhausted := false
+	for _, e := range m.exps {
+		if !e.matches(method, args) {
+			continue
+		}
+		if e.times != anyTimes && e.calls >= e.times {
+			exhausted = true
+			continue
+		}
+		e.calls++
+		return e.results
+	}
+	call := Call{Method: method, Args: args}
+	if exhausted {
+		m.t.Errorf("mock: unexpected call %v: all the matching expectations are reached", call)
+	} else {
+		m.t.Errorf("mock: unexpected call %v: no matching expectation", call)
+	}
+	return nil
+}
+
+// Calls returns the recorded calls of the given method, or all of them if
+// method is empty.
+func (m *Mock) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, c := range m.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (m *Mock) assert() {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for method, calls := range m.replay {
+		if len(calls) > 0 {
+			m.t.Errorf("replay: %d recorded calls of %s not made", len(calls), method)
+		}
+	}
+	for _, e := range m.exps {
+		call := Call{Method: e.method, Args: e.args}
+		switch {
+		case e.times != anyTimes && e.calls != e.times:
+			m.t.Errorf("mock: %v called %d times, want %d", call, e.calls, e.times)
+		case e.times == anyTimes && !e.maybe && e.calls == 0:
+			m.t.Errorf("mock: %v not called", call)
+		}
+	}
+}
+
+// Ret returns the i-th result of a call as a T, or its zero value if the
+// result is missing or nil. It is called by the generated mocks.
+func Ret[T any](results []any, i int) T {
+	var zero T
+	if i >= len(results) || results[i] == nil {
+		return zero
+	}
+	if r, ok := results[i].(replayedResult); ok {
+		v, err := decodeResult[T](r.raw)
+		if err != nil {
+			r.m.t.Helper()
+			r.m.t.Errorf("replay: %s result %d: %v", r.method, i, err)
+		}
+		return v
+	}
+	v, ok := results[i].(T)
+	if !ok {
+		panic(fmt.Sprintf("mock: result %d has type %T, want %v", i, results[i], reflect.TypeOf(&zero).Elem()))
+	}
+	return v
+}
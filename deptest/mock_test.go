@@ -0,0 +1,141 @@
+package deptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeT is a testing.TB recording the errors, whose cleanups are run by
+// finish.
+type fakeT struct {
+	testing.TB
+	errs     []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+}
+
+func (t *fakeT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+// finish runs the cleanups, and returns the errors.
+func (t *fakeT) finish() []string {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+	return t.errs
+}
+
+func TestMock(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		setup   func(m *Mock)
+		calls   [][]any // the method followed by the args of every call
+		results [][]any
+		errs    []string
+	}{
+		{
+			name:    "any times",
+			setup:   func(m *Mock) { m.On("Get").Return("v", nil) },
+			calls:   [][]any{{"Get", "a"}, {"Get", "b"}},
+			results: [][]any{{"v", nil}, {"v", nil}},
+		},
+		{
+			name:  "not called",
+			setup: func(m *Mock) { m.On("Get") },
+			errs:  []string{`mock: Get() not called`},
+		},
+		{
+			name:  "maybe",
+			setup: func(m *Mock) { m.On("Get").Maybe() },
+		},
+		{
+			name: "args",
+			setup: func(m *Mock) {
+				m.On("Get", "a").Return("A")
+				m.On("Get", "b").Return("B")
+			},
+			calls:   [][]any{{"Get", "b"}, {"Get", "a"}},
+			results: [][]any{{"B"}, {"A"}},
+		},
+		{
+			name:    "times",
+			setup:   func(m *Mock) { m.On("Get").Return(1).Times(2) },
+			calls:   [][]any{{"Get"}},
+			results: [][]any{{1}},
+			errs:    []string{`mock: Get() called 1 times, want 2`},
+		},
+		{
+			name: "times reached",
+			setup: func(m *Mock) {
+				m.On("Get").Return(1).Times(1)
+				m.On("Get").Return(2)
+			},
+			calls:   [][]any{{"Get"}, {"Get"}, {"Get"}},
+			results: [][]any{{1}, {2}, {2}},
+		},
+		{
+			name:    "times exceeded",
+			setup:   func(m *Mock) { m.On("Get").Return(1).Times(1) },
+			calls:   [][]any{{"Get"}, {"Get"}},
+			results: [][]any{{1}, nil},
+			errs:    []string{`mock: unexpected call Get(): all the matching expectations are reached`},
+		},
+		{
+			name:    "never",
+			setup:   func(m *Mock) { m.On("Delete", "a").Never() },
+			calls:   [][]any{{"Delete", "a"}},
+			results: [][]any{nil},
+			errs:    []string{`mock: unexpected call Delete("a"): all the matching expectations are reached`},
+		},
+		{
+			name:    "unexpected",
+			setup:   func(m *Mock) { m.On("Get", "a").Maybe() },
+			calls:   [][]any{{"Get", "b"}},
+			results: [][]any{nil},
+			errs:    []string{`mock: unexpected call Get("b"): no matching expectation`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ft := &fakeT{}
+			m := NewMock(ft)
+			tc.setup(m)
+			for i, call := range tc.calls {
+				got := m.Called(call[0].(string), call[1:]...)
+				if fmt.Sprint(got) != fmt.Sprint(tc.results[i]) {
+					t.Errorf("call %d: %v, want %v", i, got, tc.results[i])
+				}
+			}
+			if got := m.Calls(""); len(got) != len(tc.calls) {
+				t.Errorf("%d calls recorded, want %d", len(got), len(tc.calls))
+			}
+			errs := ft.finish()
+			if strings.Join(errs, "\n") != strings.Join(tc.errs, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(errs, "\n"), strings.Join(tc.errs, "\n"))
+			}
+		})
+	}
+}
+
+func TestRet(t *testing.T) {
+	results := []any{"v", nil}
+	if got := Ret[string](results, 0); got != "v" {
+		t.Errorf("Ret[string](0) = %q, want v", got)
+	}
+	if got := Ret[error](results, 1); got != nil {
+		t.Errorf("Ret[error](1) = %v, want nil", got)
+	}
+	if got := Ret[int](results, 2); got != 0 {
+		t.Errorf("Ret[int](2) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,186 @@
+package deptest
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cgfork/deps"
+)
+
+// Option configures Test and Bench.
+type Option func(*options)
+
+type options struct {
+	typeNames map[reflect.Type]string // by argument type
+	argNames  map[int]string          // by argument index
+
+	checkLeaks bool
+	parallel   bool
+
+	registry *deps.Registry
+	added    []deps.ModuleItem
+	removed  []removal
+
+	resets     []removal
+	recordings []recording
+}
+
+// removal is a set of registrations removed by Remove, or reset by Reset.
+type removal struct {
+	iface reflect.Type
+	names []string
+}
+
+// matches returns true if dep has one of the names of the removal, an empty
+// name meaning the anonymous implementation, or if the removal has no names.
+func (r removal) matches(dep *deps.Dep) bool {
+	if len(r.names) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(r.names, func(name string) bool {
+		return name == dep.Name() || name == "" && dep.Anonymous()
+	})
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{typeNames: map[reflect.Type]string{}, argNames: map[int]string{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// argName returns the name of the implementation passed as the i-th
+// argument of the body, of type t.
+func (o *options) argName(i int, t reflect.Type) string {
+	if name, ok := o.argNames[i]; ok {
+		return name
+	}
+	return o.typeNames[t]
+}
+
+// WithName passes the implementation with the given name to the arguments
+// of type T, an interface or a pointer to an implementation struct.
+//
+//	deptest.Test(t, config, func(t *testing.T, foo Foo) {
+//		// foo is fooA.
+//	}, deptest.WithName[Foo]("fooA"))
+func WithName[T any](name string) Option {
+	return func(o *options) {
+		o.typeNames[deps.Type[T]()] = name
+	}
+}
+
+// ArgName passes the implementation with the given name to the i-th argument
+// of the body, the first one being the *testing.T. It takes precedence over
+// WithName, and allows to get several implementations of an interface:
+//
+//	deptest.Test(t, config, func(t *testing.T, a, b Foo) {
+//		// ...
+//	}, deptest.ArgName(1, "fooA"), deptest.ArgName(2, "fooB"))
+func ArgName(i int, name string) Option {
+	return func(o *options) {
+		o.argNames[i] = name
+	}
+}
+
+// CheckLeaks fails the test if goroutines started during the test, like the
+// ones of the background services, are still running once the components
+// are shut down. It must not be used with the tests running in parallel.
+func CheckLeaks() Option {
+	return func(o *options) {
+		o.checkLeaks = true
+	}
+}
+
+// WithRegistry uses the registrations of reg instead of the global ones.
+func WithRegistry(reg *deps.Registry) Option {
+	return func(o *options) {
+		o.registry = reg
+	}
+}
+
+// Add adds the registrations of the items, made by deps.Provides,
+// deps.ProvidesFunc, deps.Supplies, deps.Decorates or deps.Include, for the
+// test only:
+//
+//	deptest.Test(t, config, func(t *testing.T, bar Bar) {
+//		// ...
+//	}, deptest.Remove[Foo](), deptest.Add(deps.Provides[Foo, testFoo]()))
+func Add(items ...deps.ModuleItem) Option {
+	return func(o *options) {
+		o.added = append(o.added, items...)
+	}
+}
+
+// Remove removes the registrations of T with the given names, an empty name
+// meaning the anonymous implementation, or all the implementations of T if
+// no name is given, for the test only. The decorators of T are only removed
+// if their names are given. The registrations are removed before the ones of
+// Add are added.
+func Remove[T any](names ...string) Option {
+	return func(o *options) {
+		o.removed = append(o.removed, removal{deps.Type[T](), names})
+	}
+}
+
+// Reset rebuilds the implementations of T with the given names, an empty
+// name meaning the anonymous implementation, or all of them if no name is
+// given, for every case of a Fixture, together with the components referring
+// to them. The implementations of the modules of the config are reset too.
+// The other components are shared by the cases.
+func Reset[T any](names ...string) Option {
+	return func(o *options) {
+		o.resets = append(o.resets, removal{deps.Type[T](), names})
+	}
+}
+
+// Parallel runs the sub-test of Test in parallel with the other parallel
+// tests. It has no effect on Bench.
+func Parallel() Option {
+	return func(o *options) {
+		o.parallel = true
+	}
+}
+
+// registrations returns the registrations used by the test. The global
+// registry is never modified.
+func (o *options) registrations() ([]*deps.Dep, error) {
+	base := deps.Registered()
+	if o.registry != nil {
+		base = o.registry.Deps()
+	}
+	if len(o.added) == 0 && len(o.removed) == 0 {
+		return base, nil
+	}
+	reg, err := deps.NewRegistry(base...)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range o.removed {
+		reg.Remove(r.iface, r.names...)
+	}
+	if err := reg.Add(o.added...); err != nil {
+		return nil, err
+	}
+	return reg.Deps(), nil
+}
+
+// TOML returns the TOML config made of the given sections, typically config
+// structs, to be used as deps.Config.Config:
+//
+//	config := deps.Config{Config: deptest.TOML(t, map[string]any{
+//		"fooA":   fooConfig{Name: "a"},
+//		"module": map[string]any{"main.Bar": barConfig{Size: 2}},
+//	})}
+func TOML(t testing.TB, sections map[string]any) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := toml.NewEncoder(&buf).Encode(sections); err != nil {
+		t.Fatalf("deptest.TOML: %v", err)
+	}
+	return buf.String()
+}
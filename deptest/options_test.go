@@ -0,0 +1,208 @@
+package deptest
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cgfork/deps"
+)
+
+type greeter interface {
+	Greet(name string) (string, error)
+}
+
+type hello struct {
+	deps.Implements[greeter]
+}
+
+func (g *hello) Greet(name string) (string, error) { return "hello " + name, nil }
+
+type bonjour struct {
+	deps.Implements[greeter] `impl:"bonjour"`
+}
+
+func (g *bonjour) Greet(name string) (string, error) { return "bonjour " + name, nil }
+
+type salut struct {
+	deps.Implements[greeter]
+}
+
+func (g *salut) Greet(name string) (string, error) { return "salut " + name, nil }
+
+// newRegistry returns a registry of the given items.
+func newRegistry(t testing.TB, items ...deps.ModuleItem) *deps.Registry {
+	t.Helper()
+	reg, err := deps.NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Add(items...); err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestRegistryOptions(t *testing.T) {
+	reg := newRegistry(t, deps.Provides[greeter, hello](), deps.Provides[greeter, bonjour]())
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "registry",
+			want: "hello you",
+		},
+		{
+			name: "remove anonymous",
+			opts: []Option{Remove[greeter](""), Add(deps.Provides[greeter, salut]())},
+			want: "salut you",
+		},
+		{
+			name: "remove all",
+			opts: []Option{Remove[greeter](), Add(deps.Provides[greeter, salut]())},
+			want: "salut you",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			Test(t, deps.Config{}, func(t *testing.T, g greeter) {
+				if got, _ := g.Greet("you"); got != tc.want {
+					t.Errorf("Greet() = %q, want %q", got, tc.want)
+				}
+			}, append(tc.opts, WithRegistry(reg))...)
+		})
+	}
+
+	// The registry is left untouched.
+	if n := len(reg.Deps()); n != 2 {
+		t.Errorf("%d registrations, want 2", n)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	reg := newRegistry(t, deps.Provides[greeter, hello](), deps.Provides[greeter, bonjour]())
+	cases := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "hello you"},
+		{[]Option{Remove[greeter](""), Add(deps.Provides[greeter, salut]())}, "salut you"},
+		{[]Option{Remove[greeter](""), WithName[greeter]("bonjour")}, "bonjour you"},
+		{[]Option{Remove[greeter](), Add(deps.Provides[greeter, salut]())}, "salut you"},
+	}
+	// The first bodies, as many as the tests allowed to run in parallel,
+	// wait for each other, so they only complete if they run in parallel.
+	parallel := min(len(cases), flag.Lookup("test.parallel").Value.(flag.Getter).Get().(int))
+	var started atomic.Int32
+	var ready sync.WaitGroup
+	ready.Add(parallel)
+	all := make(chan struct{})
+	go func() {
+		ready.Wait()
+		close(all)
+	}()
+
+	t.Run("group", func(t *testing.T) {
+		for _, tc := range cases {
+			tc := tc
+			Test(t, deps.Config{}, func(t *testing.T, g greeter) {
+				if started.Add(1) <= int32(parallel) {
+					ready.Done()
+				}
+				select {
+				case <-all:
+				case <-time.After(5 * time.Second):
+					t.Fatal("the tests don't run in parallel")
+				}
+				if got, _ := g.Greet("you"); got != tc.want {
+					t.Errorf("Greet() = %q, want %q", got, tc.want)
+				}
+			}, append(tc.opts, WithRegistry(reg), Parallel())...)
+		}
+	})
+
+	// The registry is left untouched.
+	if n := len(reg.Deps()); n != 2 {
+		t.Errorf("%d registrations, want 2", n)
+	}
+}
+
+func TestNameOptions(t *testing.T) {
+	reg := newRegistry(t, deps.Provides[greeter, hello](), deps.Provides[greeter, bonjour]())
+	greet := func(t *testing.T, g greeter) string {
+		t.Helper()
+		got, err := g.Greet("you")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	t.Run("by type", func(t *testing.T) {
+		Test(t, deps.Config{}, func(t *testing.T, g greeter) {
+			if got := greet(t, g); got != "bonjour you" {
+				t.Errorf("Greet() = %q, want bonjour", got)
+			}
+		}, WithRegistry(reg), WithName[greeter]("bonjour"))
+	})
+	t.Run("by index", func(t *testing.T) {
+		Test(t, deps.Config{}, func(t *testing.T, a, b greeter) {
+			if got := greet(t, a) + ", " + greet(t, b); got != "hello you, bonjour you" {
+				t.Errorf("Greet() = %q, want hello then bonjour", got)
+			}
+		}, WithRegistry(reg), ArgName(2, "bonjour"))
+	})
+	t.Run("index over type", func(t *testing.T) {
+		Test(t, deps.Config{}, func(t *testing.T, a, b greeter) {
+			if got := greet(t, a) + ", " + greet(t, b); got != "hello you, bonjour you" {
+				t.Errorf("Greet() = %q, want hello then bonjour", got)
+			}
+		}, WithRegistry(reg), WithName[greeter]("bonjour"), ArgName(1, ""))
+	})
+
+	body := func(t *testing.T, a, b greeter) {}
+	for _, i := range []int{0, 3} {
+		_, _, err := checkRunFunc(t, body, newOptions([]Option{ArgName(i, "bonjour")}))
+		if want := "out of range"; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ArgName(%d): error %v, want %q", i, err, want)
+		}
+	}
+}
+
+type tomlConfig struct {
+	Name  string
+	Size  int
+	Hosts []string
+}
+
+func TestTOML(t *testing.T) {
+	sections := map[string]any{
+		"fooA":   tomlConfig{Name: "a", Size: 2, Hosts: []string{"h1", "h2"}},
+		"module": map[string]any{"main.Bar": tomlConfig{Name: "bar"}},
+	}
+	var got struct {
+		FooA   tomlConfig            `toml:"fooA"`
+		Module map[string]tomlConfig `toml:"module"`
+	}
+	if _, err := toml.Decode(TOML(t, sections), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.FooA, sections["fooA"]) {
+		t.Errorf("fooA = %+v, want %+v", got.FooA, sections["fooA"])
+	}
+	if want := map[string]tomlConfig{"main.Bar": {Name: "bar"}}; !reflect.DeepEqual(got.Module, want) {
+		t.Errorf("module = %+v, want %+v", got.Module, want)
+	}
+
+	ft := &fakeT{}
+	TOML(ft, map[string]any{"bad": make(chan int)})
+	if len(ft.errs) != 1 || !strings.HasPrefix(ft.errs[0], "deptest.TOML: ") {
+		t.Errorf("errors %q, want the encoding error", ft.errs)
+	}
+}
@@ -0,0 +1,288 @@
+package deptest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/cgfork/deps"
+)
+
+var recordFlag = flag.Bool("deps.record", false, "record the golden files of deptest.Recorded")
+
+// Mocker is implemented by the mocks generated by depsmock.
+type Mocker interface {
+	DepsMock() *Mock
+}
+
+// recording is a component recorded or replayed by Recorded.
+type recording struct {
+	iface reflect.Type
+	name  string
+	file  string
+	mock  Mocker
+}
+
+// Recorded records the calls of the implementation of T with the given name,
+// an empty name meaning the anonymous one, and their results to the golden
+// file, or replays them. mock is a mock of T generated by depsmock:
+//
+//	deptest.Test(t, config, func(t *testing.T, bar Bar) {
+//		// ...
+//	}, deptest.Recorded[Foo]("", "testdata/foo.json", NewMockFoo(t)))
+//
+// The calls are recorded if the test is run with the -deps.record flag or
+// the DEPS_RECORD environment variable: the consumers of T get the mock,
+// which forwards the calls to the real implementation. The mock wraps the
+// instance returned by the hook of the implementation, if any. Otherwise the
+// mock replaces the implementation, which is not constructed, and returns
+// the recorded results; the test fails if the golden file is missing or if
+// the calls differ from the recorded ones. The args and the results are
+// encoded in JSON, and the errors are replayed as errors with the same
+// message. The results of the other interface types can't be replayed.
+func Recorded[T any](name, file string, mock Mocker) Option {
+	return func(o *options) {
+		o.recordings = append(o.recordings, recording{deps.Type[T](), name, file, mock})
+	}
+}
+
+// recordMode returns true if the calls must be recorded to the golden files
+// rather than replayed.
+func recordMode() bool {
+	return *recordFlag || os.Getenv("DEPS_RECORD") != ""
+}
+
+// record sets up the recordings of the options, and returns the config and
+// the registrations to use.
+func (o *options) record(t testing.TB, config deps.Config, regs []*deps.Dep) (deps.Config, []*deps.Dep) {
+	t.Helper()
+	if len(o.recordings) == 0 {
+		return config, regs
+	}
+	config.NamedFakes = slices.Clone(config.NamedFakes)
+	regs = slices.Clone(regs)
+	for _, r := range o.recordings {
+		if !reflect.TypeOf(r.mock).Implements(r.iface) {
+			t.Fatalf("deptest.Recorded: %T does not implement %v", r.mock, r.iface)
+		}
+		m := r.mock.DepsMock()
+
+		if !recordMode() {
+			calls, err := readGolden(r.file)
+			if errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("deptest.Recorded: %v; run the test with -deps.record to record it", err)
+			}
+			if err != nil {
+				t.Fatalf("deptest.Recorded: %v", err)
+			}
+			m.setReplay(calls)
+			config.NamedFakes = append(config.NamedFakes, deps.Fake{Iface: r.iface, Name: r.name, Impl: r.mock})
+			continue
+		}
+
+		found := false
+		for i, dep := range regs {
+			if dep.Iface() != r.iface || !(dep.Name() == r.name || r.name == "" && dep.Anonymous()) {
+				continue
+			}
+			mock := r.mock
+			// The mock wraps the instance returned by the existing hook.
+			prev := dep.Hook()
+			regs[i] = dep.With(deps.WithHook(func(impl any, caller string) any {
+				if prev != nil {
+					impl = prev(impl, caller)
+				}
+				m.forwardTo(impl)
+				return mock
+			}))
+			found = true
+		}
+		if !found {
+			t.Fatalf("deptest.Recorded: %v named %q not registered", r.iface, r.name)
+		}
+		file := r.file
+		t.Cleanup(func() {
+			if err := writeGolden(file, m.Calls("")); err != nil {
+				t.Errorf("deptest.Recorded: %v", err)
+			}
+		})
+	}
+	return config, regs
+}
+
+// recorded is a call of a golden file.
+type recorded struct {
+	Method  string            `json:"method"`
+	Args    []json.RawMessage `json:"args,omitempty"`
+	Results []json.RawMessage `json:"results,omitempty"`
+}
+
+// golden is the content of a golden file.
+type golden struct {
+	Calls []recorded `json:"calls"`
+}
+
+func readGolden(file string) ([]recorded, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var g golden
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return g.Calls, nil
+}
+
+func writeGolden(file string, calls []Call) error {
+	g := golden{Calls: []recorded{}}
+	for _, c := range calls {
+		g.Calls = append(g.Calls, recorded{
+			Method:  c.Method,
+			Args:    encodeValues(c.Args),
+			Results: encodeValues(c.Results),
+		})
+	}
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(b, '\n'), 0o644)
+}
+
+func encodeValues(vs []any) []json.RawMessage {
+	var raws []json.RawMessage
+	for _, v := range vs {
+		raws = append(raws, encodeValue(v))
+	}
+	return raws
+}
+
+// encodeValue encodes v in JSON. The errors are encoded as their message, the
+// contexts and the values which can't be encoded as their type.
+func encodeValue(v any) json.RawMessage {
+	var x any
+	switch v := v.(type) {
+	case nil:
+		return json.RawMessage("null")
+	case error:
+		x = struct {
+			Error string `json:"error"`
+		}{v.Error()}
+	case context.Context:
+		x = "context"
+	default:
+		x = v
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%T", v))
+	}
+	return b
+}
+
+// replayedResult is a result of a replayed call, decoded by Ret.
+type replayedResult struct {
+	m      *Mock
+	method string
+	raw    json.RawMessage
+}
+
+// decodeResult decodes a result encoded by encodeValue.
+func decodeResult[T any](raw json.RawMessage) (T, error) {
+	var v T
+	if string(raw) == "null" {
+		return v, nil
+	}
+	typ := reflect.TypeOf(&v).Elem()
+	switch {
+	case typ == reflect.TypeOf((*error)(nil)).Elem():
+		var e struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return v, fmt.Errorf("decoding error %s: %w", raw, err)
+		}
+		return any(errors.New(e.Error)).(T), nil
+	case typ.Kind() == reflect.Interface:
+		return v, fmt.Errorf("can't replay the result %s of interface type %v", raw, typ)
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, fmt.Errorf("decoding result %s as %v: %w", raw, typ, err)
+	}
+	return v, nil
+}
+
+// forwardTo makes the mock forward the calls to impl, and record them.
+func (m *Mock) forwardTo(impl any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.target = reflect.ValueOf(impl)
+}
+
+// setReplay makes the mock replay the given calls.
+func (m *Mock) setReplay(calls []recorded) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replay = map[string][]recorded{}
+	for _, c := range calls {
+		m.replay[c.Method] = append(m.replay[c.Method], c)
+	}
+}
+
+// replayed returns the results of the next recorded call of the method. The
+// mock must be locked.
+func (m *Mock) replayed(method string, args []any) []any {
+	m.t.Helper()
+	calls := m.replay[method]
+	if len(calls) == 0 {
+		m.t.Errorf("replay: unexpected call %v", Call{Method: method, Args: args})
+		return nil
+	}
+	c := calls[0]
+	m.replay[method] = calls[1:]
+	want, _ := json.Marshal(c.Args)
+	got, _ := json.Marshal(encodeValues(args))
+	if string(got) != string(want) {
+		m.t.Errorf("replay: %s called with %s, recorded %s", method, got, want)
+	}
+	results := make([]any, len(c.Results))
+	for i, r := range c.Results {
+		results[i] = replayedResult{m, method, r}
+	}
+	return results
+}
+
+// forward calls the method of target with args.
+func forward(target reflect.Value, method string, args []any) []any {
+	fn := target.MethodByName(method)
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			in[i] = reflect.Zero(fn.Type().In(i))
+		} else {
+			in[i] = reflect.ValueOf(arg)
+		}
+	}
+	var out []reflect.Value
+	if fn.Type().IsVariadic() {
+		out = fn.CallSlice(in)
+	} else {
+		out = fn.Call(in)
+	}
+	results := make([]any, len(out))
+	for i, v := range out {
+		results[i] = v.Interface()
+	}
+	return results
+}
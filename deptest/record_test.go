@@ -0,0 +1,122 @@
+package deptest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cgfork/deps"
+)
+
+// mockGreeter is a mock of greeter, like the ones generated by depsmock.
+type mockGreeter struct {
+	Mock *Mock
+}
+
+func newMockGreeter(t testing.TB) *mockGreeter {
+	return &mockGreeter{Mock: NewMock(t)}
+}
+
+func (m *mockGreeter) DepsMock() *Mock {
+	return m.Mock
+}
+
+func (m *mockGreeter) Greet(name string) (string, error) {
+	results := m.Mock.Called("Greet", name)
+	return Ret[string](results, 0), Ret[error](results, 1)
+}
+
+// shout is the hook of a greeter, shouting the greetings.
+type shout struct {
+	greeter
+}
+
+func (s shout) Greet(name string) (string, error) {
+	g, err := s.greeter.Greet(name)
+	return strings.ToUpper(g), err
+}
+
+// setRecord sets the -deps.record flag until t ends.
+func setRecord(t *testing.T, record bool) {
+	old := *recordFlag
+	*recordFlag = record
+	t.Cleanup(func() { *recordFlag = old })
+}
+
+func TestRecorded(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []deps.Option
+		want string
+	}{
+		{
+			name: "plain",
+			want: "hello you",
+		},
+		{
+			name: "hook",
+			opts: []deps.Option{deps.WithHook(func(impl any, _ string) any { return shout{impl.(greeter)} })},
+			want: "HELLO YOU",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("DEPS_RECORD", "")
+			file := filepath.Join(t.TempDir(), "greeter.json")
+			reg := newRegistry(t, deps.Provides[greeter, hello](tc.opts...))
+			body := func(t *testing.T, g greeter) {
+				if got, err := g.Greet("you"); got != tc.want || err != nil {
+					t.Errorf("Greet() = %q, %v, want %q", got, err, tc.want)
+				}
+			}
+
+			setRecord(t, true)
+			Test(t, deps.Config{}, body, WithRegistry(reg), Recorded[greeter]("", file, newMockGreeter(t)))
+			b, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var g golden
+			if err := json.Unmarshal(b, &g); err != nil {
+				t.Fatal(err)
+			}
+			if len(g.Calls) != 1 || g.Calls[0].Method != "Greet" {
+				t.Fatalf("recorded %s, want a call of Greet", b)
+			}
+
+			// The implementation isn't constructed when replayed.
+			setRecord(t, false)
+			empty := newRegistry(t, deps.Provides[greeter, salut]())
+			Test(t, deps.Config{}, body, WithRegistry(empty), Recorded[greeter]("", file, newMockGreeter(t)))
+		})
+	}
+}
+
+func TestRecordedMissing(t *testing.T) {
+	t.Setenv("DEPS_RECORD", "")
+	setRecord(t, false)
+	ft := &fakeT{}
+	o := newOptions([]Option{Recorded[greeter]("", filepath.Join(t.TempDir(), "missing.json"), newMockGreeter(ft))})
+	o.record(ft, deps.Config{}, nil)
+	// fakeT doesn't stop the test on Fatalf, so only the first error counts.
+	if len(ft.errs) == 0 || !strings.Contains(ft.errs[0], "run the test with -deps.record") {
+		t.Errorf("errors %q, want the missing golden file", ft.errs)
+	}
+}
+
+func TestReplayInterfaceResult(t *testing.T) {
+	ft := &fakeT{}
+	m := NewMock(ft)
+	m.setReplay([]recorded{{
+		Method:  "Get",
+		Results: []json.RawMessage{json.RawMessage(`"hello"`)},
+	}})
+	results := m.Called("Get")
+	if g := Ret[greeter](results, 0); g != nil {
+		t.Errorf("Ret() = %v, want nil", g)
+	}
+	if len(ft.errs) != 1 || !strings.Contains(ft.errs[0], "replay: Get result 0: can't replay") {
+		t.Errorf("errors %q, want the interface result error", ft.errs)
+	}
+}
@@ -15,6 +15,9 @@ import (
 //	func(*testing.T, IfaceType)
 //	func(*testing.T, IfaceType1, IfaceType2)
 //
+// The interface arguments get the anonymous implementations, unless named
+// by the options.
+//
 // # example
 //
 //	 func TestFoo(t *testing.T) {
@@ -22,45 +25,128 @@ import (
 //				// Testing code
 //			})
 //	 }
-func Test(t *testing.T, config deps.Config, body any) {
+func Test(t *testing.T, config deps.Config, body any, opts ...Option) {
 	t.Helper()
+	o := newOptions(opts)
 	t.Run("depstest", func(t *testing.T) {
-		run(t, config, body)
+		if o.parallel {
+			t.Parallel()
+		}
+		run(t, config, body, o)
+	})
+}
+
+// Bench runs a sub-benchmark of b that benchmarks the supplied code. The
+// components are constructed before the timer is reset, and body loops
+// over b.N:
+//
+//	deptest.Bench(b, config, func(b *testing.B, foo Foo) {
+//		for i := 0; i < b.N; i++ {
+//			foo.Get()
+//		}
+//	})
+func Bench(b *testing.B, config deps.Config, body any, opts ...Option) {
+	b.Helper()
+	o := newOptions(opts)
+	b.Run("depsbench", func(b *testing.B) {
+		run(b, config, body, o)
 	})
 }
 
-// Bench runs a sub-benchmark of b that benchmarks the supplied code.
-func Bench(b *testing.B, config deps.Config, body any) {
+// BenchParallel runs a sub-benchmark of b that benchmarks the supplied code
+// in parallel with b.RunParallel. body is a function like:
+//
+//	func(pb *testing.PB, foo Foo) {
+//		for pb.Next() {
+//			foo.Get()
+//		}
+//	}
+//
+// The components are constructed once, before the timer is reset, and shared
+// by the goroutines.
+func BenchParallel(b *testing.B, config deps.Config, body any, opts ...Option) {
 	b.Helper()
+	o := newOptions(opts)
 	b.Run("depsbench", func(b *testing.B) {
-		run(b, config, body)
+		call, _, err := checkRunFunc((*testing.PB)(nil), body, o)
+		if err != nil {
+			b.Fatal(fmt.Errorf("depstest.BenchParallel argument: %v", err))
+		}
+		runner := start(b, config, o)
+		invoke, err := call(runner)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			invoke(pb)
+		})
 	})
 }
 
-func run(t testing.TB, config deps.Config, testBody any) {
+func run(t testing.TB, config deps.Config, testBody any, o *options) {
 	t.Helper()
-	body, _, err := checkRunFunc(t, testBody)
+	body, _, err := checkRunFunc(t, testBody, o)
 	if err != nil {
 		t.Fatal(fmt.Errorf("depstest.run argument: %v", err))
 	}
 
+	runner := start(t, config, o)
+	invoke, err := body(runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := t.(*testing.B); ok {
+		// Exclude the construction of the components.
+		b.ResetTimer()
+	}
+	invoke(t)
+}
+
+// start returns a new runtime, shut down when t ends.
+func start(t testing.TB, config deps.Config, o *options) deps.Runtime {
+	t.Helper()
+	if o.checkLeaks {
+		// Registered first, so that it runs after the shutdown.
+		t.Cleanup(checkLeaks(t))
+	}
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	runner, err := deps.NewRuntime(ctx, deps.Registered(), config)
+	t.Cleanup(cancel)
+	regs, err := o.registrations()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := body(ctx, runner); err != nil {
+	config, regs = o.record(t, config, regs)
+	runner, err := deps.NewRuntime(ctx, regs, config)
+	if err != nil {
 		t.Fatal(err)
 	}
+	t.Cleanup(func() { shutdown(t, runner, config) })
+	return runner
+}
+
+// shutdown shuts the runner down, and fails t on error.
+func shutdown(t testing.TB, runner deps.Runtime, config deps.Config) {
+	t.Helper()
+	timeout := config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = deps.DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := runner.(deps.Shutdowner).Shutdown(ctx); err != nil {
+		t.Errorf("deptest: shutdown: %v", err)
+	}
 }
 
 // checkRunFunc checks that the type of the function passed to depstest.Run
-// is correct (its first argument matches t and its remaining arguments are
-// either component interfaces or pointer to component implementations). On
-// success it returns (1) a function that gets the components and passes them
-// to fn and (2) the interface types of the component implementation arguments.
-func checkRunFunc(t testing.TB, fn any) (func(context.Context, deps.Runtime) error, []reflect.Type, error) {
+// is correct (its first argument matches the type of first, like a
+// *testing.T, and its remaining arguments are either component interfaces or
+// pointer to component implementations). On success it returns (1) a
+// function that gets the components and returns a function passing them to
+// fn with the first argument and (2) the interface types of the component
+// implementation arguments.
+func checkRunFunc(first any, fn any, o *options) (func(deps.Runtime) (func(first any), error), []reflect.Type, error) {
 	fnType := reflect.TypeOf(fn)
 	if fnType == nil || fnType.Kind() != reflect.Func {
 		return nil, nil, fmt.Errorf("not a func")
@@ -75,8 +161,34 @@ func checkRunFunc(t testing.TB, fn any) (func(context.Context, deps.Runtime) err
 	if fnType.NumOut() > 0 {
 		return nil, nil, fmt.Errorf("must have no return outputs")
 	}
-	if fnType.In(0) != reflect.TypeOf(t) {
-		return nil, nil, fmt.Errorf("function first argument type %v does not match first deptest.Test argument %T", fnType.In(0), t)
+	if fnType.In(0) != reflect.TypeOf(first) {
+		return nil, nil, fmt.Errorf("function first argument type %v does not match %T", fnType.In(0), first)
+	}
+	intfs, err := checkComponentArgs(fnType, n, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return func(runner deps.Runtime) (func(any), error) {
+		comps, err := getComponents(runner, fnType, n, o)
+		if err != nil {
+			return nil, err
+		}
+		return func(first any) {
+			args := append([]reflect.Value{reflect.ValueOf(first)}, comps...)
+			reflect.ValueOf(fn).Call(args)
+		}, nil
+	}, intfs, nil
+}
+
+// checkComponentArgs checks that the arguments of fnType from the second one
+// to the n-th one are component interfaces or pointer to component
+// implementations, and returns the interface types of the latter.
+func checkComponentArgs(fnType reflect.Type, n int, o *options) ([]reflect.Type, error) {
+	for i := range o.argNames {
+		if i < 1 || i >= n {
+			return nil, fmt.Errorf("named argument %d out of range", i)
+		}
 	}
 	var intfs []reflect.Type
 	for i := 1; i < n; i++ {
@@ -86,39 +198,41 @@ func checkRunFunc(t testing.TB, fn any) (func(context.Context, deps.Runtime) err
 		case reflect.Pointer:
 			intf, err := extractComponentInterfaceType(fnType.In(i).Elem())
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 			intfs = append(intfs, intf)
 		default:
-			return nil, nil, fmt.Errorf("function argument %d type %v must be a component interface or pointer to component implementation", i, fnType.In(i))
+			return nil, fmt.Errorf("function argument %d type %v must be a component interface or pointer to component implementation", i, fnType.In(i))
 		}
 	}
+	return intfs, nil
+}
 
-	return func(_ context.Context, runner deps.Runtime) error {
-		args := make([]reflect.Value, n)
-		args[0] = reflect.ValueOf(t)
-		for i := 1; i < n; i++ {
-			argType := fnType.In(i)
-			switch argType.Kind() {
-			case reflect.Interface:
-				comp, err := runner.GetIntf(argType, "")
-				if err != nil {
-					return err
-				}
-				args[i] = reflect.ValueOf(comp)
-			case reflect.Pointer:
-				comp, err := runner.GetImpl(argType.Elem())
-				if err != nil {
-					return err
-				}
-				args[i] = reflect.ValueOf(comp)
-			default:
-				return fmt.Errorf("argument %v has unexpected type %v", i, argType)
+// getComponents returns the components passed as the arguments of fnType
+// from the second one to the n-th one.
+func getComponents(runner deps.Runtime, fnType reflect.Type, n int, o *options) ([]reflect.Value, error) {
+	var comps []reflect.Value
+	for i := 1; i < n; i++ {
+		argType := fnType.In(i)
+		name := o.argName(i, argType)
+		switch argType.Kind() {
+		case reflect.Interface:
+			comp, err := runner.GetIntf(argType, name)
+			if err != nil {
+				return nil, err
 			}
+			comps = append(comps, reflect.ValueOf(comp))
+		case reflect.Pointer:
+			comp, err := runner.(deps.NamedResolver).GetNamedImpl(argType.Elem(), name)
+			if err != nil {
+				return nil, err
+			}
+			comps = append(comps, reflect.ValueOf(comp))
+		default:
+			return nil, fmt.Errorf("argument %v has unexpected type %v", i, argType)
 		}
-		reflect.ValueOf(fn).Call(args)
-		return nil
-	}, intfs, nil
+	}
+	return comps, nil
 }
 
 // extractComponentInterfaceType extracts the component interface type from the
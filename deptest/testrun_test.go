@@ -0,0 +1,126 @@
+package deptest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cgfork/deps"
+)
+
+// failing fails to shut down.
+type failing struct {
+	deps.Implements[greeter]
+}
+
+func (f *failing) Greet(name string) (string, error) { return "failing " + name, nil }
+
+func (f *failing) Shutdown(context.Context) error {
+	return errors.New("boom")
+}
+
+func TestShutdownError(t *testing.T) {
+	reg := newRegistry(t, deps.Provides[greeter, failing]())
+	ft := &fakeT{}
+	runner := start(ft, deps.Config{}, newOptions([]Option{WithRegistry(reg)}))
+	if _, err := runner.GetIntf(deps.Type[greeter](), ""); err != nil {
+		t.Fatal(err)
+	}
+	errs := ft.finish()
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "deptest: shutdown: ") || !strings.Contains(errs[0], "boom") {
+		t.Errorf("errors %q, want the shutdown error", errs)
+	}
+}
+
+// slowInit is the time spent in the Init method of slow.
+const slowInit = 50 * time.Millisecond
+
+type slow struct {
+	deps.Implements[greeter]
+}
+
+func (s *slow) Init(context.Context) error {
+	time.Sleep(slowInit)
+	return nil
+}
+
+func (s *slow) Greet(name string) (string, error) { return "slow " + name, nil }
+
+// setBenchtime sets the -test.benchtime flag until t ends.
+func setBenchtime(t *testing.T, value string) {
+	f := flag.Lookup("test.benchtime")
+	old := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = f.Value.Set(old) })
+}
+
+func TestBench(t *testing.T) {
+	setBenchtime(t, "100x")
+	reg := newRegistry(t, deps.Provides[greeter, slow]())
+	var (
+		lastN   int
+		calls   int
+		elapsed time.Duration
+	)
+	testing.Benchmark(func(b *testing.B) {
+		Bench(b, deps.Config{}, func(b *testing.B, g greeter) {
+			lastN = b.N
+			elapsed = max(elapsed, b.Elapsed())
+			for i := 0; i < b.N; i++ {
+				g.Greet("you")
+				calls++
+			}
+		}, WithRegistry(reg))
+	})
+	if lastN != 100 {
+		t.Errorf("b.N = %d, want 100", lastN)
+	}
+	// The body also runs once with b.N = 1.
+	if calls < 100 {
+		t.Errorf("%d calls, want at least 100", calls)
+	}
+	// The timer is reset after the construction.
+	if elapsed >= slowInit {
+		t.Errorf("%v elapsed when the body started, want the construction excluded", elapsed)
+	}
+}
+
+func TestBenchParallel(t *testing.T) {
+	setBenchtime(t, "100x")
+	reg := newRegistry(t, deps.Provides[greeter, slow]())
+	var (
+		calls  atomic.Int64
+		nilPB  atomic.Bool
+		greets sync.Map
+	)
+	testing.Benchmark(func(b *testing.B) {
+		BenchParallel(b, deps.Config{}, func(pb *testing.PB, g greeter) {
+			if pb == nil {
+				nilPB.Store(true)
+				return
+			}
+			for pb.Next() {
+				got, _ := g.Greet("you")
+				greets.Store(got, true)
+				calls.Add(1)
+			}
+		}, WithRegistry(reg))
+	})
+	if nilPB.Load() {
+		t.Error("the body got a nil *testing.PB")
+	}
+	// The body also runs once with b.N = 1.
+	if n := calls.Load(); n < 100 {
+		t.Errorf("%d calls, want at least 100", n)
+	}
+	if _, ok := greets.Load("slow you"); !ok {
+		t.Error("the body didn't get the component")
+	}
+}
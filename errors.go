@@ -0,0 +1,143 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ErrNotRegistered is returned, wrapped, when a requested interface or
+// implementation has not been registered.
+var ErrNotRegistered = errors.New("not registered")
+
+// ResolveError is returned by a runtime when a dep can't be constructed. It
+// wraps the cause, like an *InitError, a *ConfigError, a *CycleError or
+// ErrNotRegistered.
+type ResolveError struct {
+	// Path contains the names of the deps being resolved, from the one
+	// requested from the runtime to the one which failed.
+	Path []string
+	Err  error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("resolving %s: %v", strings.Join(e.Path, " -> "), e.Err)
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// InitError is returned, wrapped, when the Init method or the constructor
+// function of a dep fails.
+type InitError struct {
+	// Dep is the name of the dep.
+	Dep string
+	Err error
+}
+
+func (e *InitError) Error() string {
+	return fmt.Sprintf("dep %q initialization failed: %v", e.Dep, e.Err)
+}
+
+func (e *InitError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError is returned, wrapped, when the config section of a dep can't
+// be decoded or is invalid.
+type ConfigError struct {
+	// Section is the name of the config section.
+	Section string
+	// Key is the faulty key of the section, if known.
+	Key string
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("section %q key %q: %v", e.Section, e.Key, e.Err)
+	}
+	return fmt.Sprintf("section %q: %v", e.Section, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// CycleError is returned, wrapped, when the deps refer to each other.
+type CycleError struct {
+	// Path contains the names of the deps of the cycle, the first one being
+	// repeated at the end.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// lastKeyRegexp matches the key in the decoding errors of the toml package.
+var lastKeyRegexp = regexp.MustCompile(`\(last key "([^"]*)"\)`)
+
+// decodeError returns the error decoding the given section.
+func decodeError(section string, err error) error {
+	var pe toml.ParseError
+	if errors.As(err, &pe) {
+		return &ConfigError{Section: section, Key: pe.LastKey, Err: err}
+	}
+	key := ""
+	if m := lastKeyRegexp.FindStringSubmatch(err.Error()); m != nil {
+		key = m[1]
+	}
+	return &ConfigError{Section: section, Key: key, Err: err}
+}
+
+// enter pushes dep onto the stack of the deps being constructed, and fails
+// if dep is already being constructed.
+func (r *runtime) enter(dep *Dep) error {
+	for i, d := range r.resolving {
+		if d == dep {
+			path := labels(r.resolving[i:])
+			return &CycleError{Path: append(path, dep.label())}
+		}
+	}
+	r.resolving = append(r.resolving, dep)
+	return nil
+}
+
+// leave pops the last dep pushed by enter. The error of the first dep
+// constructed is wrapped in a ResolveError, with the path of the dep which
+// failed, including the deps resolved by other runtimes.
+func (r *runtime) leave(err error) error {
+	if err != nil && r.failed == nil {
+		r.failed = labels(r.resolving)
+	}
+	r.resolving = r.resolving[:len(r.resolving)-1]
+	if len(r.resolving) > 0 {
+		return err
+	}
+	path := r.failed
+	r.failed = nil
+	if err == nil {
+		return nil
+	}
+	var re *ResolveError
+	if errors.As(err, &re) {
+		// The error of a dep resolved by another runtime, like the parent
+		// of a child runtime: its path continues the one of this runtime.
+		return &ResolveError{Path: append(path[:len(path):len(path)], re.Path...), Err: re.Err}
+	}
+	return &ResolveError{Path: path, Err: err}
+}
+
+// labels returns the labels of deps.
+func labels(deps []*Dep) []string {
+	names := make([]string, len(deps))
+	for i, dep := range deps {
+		names[i] = dep.label()
+	}
+	return names
+}
@@ -0,0 +1,132 @@
+package deps
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+type errA interface{}
+type errB interface{}
+type errC interface{}
+type errMissing interface{}
+
+var errInit = errors.New("init failed")
+
+func newErrA(errB) (errA, error) { return struct{}{}, nil }
+func newErrB(errC) (errB, error) { return struct{}{}, nil }
+func newErrC() (errC, error)     { return nil, errInit }
+
+type errConfigImpl struct {
+	Implements[errC]
+	WithConfig[childDBConfig] `section:"db"`
+}
+
+func TestResolveError(t *testing.T) {
+	a, b, c := typeName(Type[errA]()), typeName(Type[errB]()), typeName(Type[errC]())
+	for _, tc := range []struct {
+		name   string
+		deps   []*Dep
+		config string
+		child  bool // resolve errA through a child runtime overriding it
+		path   []string
+		check  func(*testing.T, error)
+	}{
+		{
+			name: "not registered",
+			deps: []*Dep{
+				mustDep(NewFuncDep[errA](newErrA)),
+				mustDep(NewFuncDep[errB](func(errMissing) (errB, error) { return struct{}{}, nil })),
+			},
+			path: []string{a, b},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrNotRegistered) {
+					t.Errorf("%v is not ErrNotRegistered", err)
+				}
+			},
+		},
+		{
+			name: "init",
+			deps: []*Dep{
+				mustDep(NewFuncDep[errA](newErrA)),
+				mustDep(NewFuncDep[errB](newErrB)),
+				mustDep(NewFuncDep[errC](newErrC)),
+			},
+			path: []string{a, b, c},
+			check: func(t *testing.T, err error) {
+				var ie *InitError
+				if !errors.As(err, &ie) || ie.Dep != c || !errors.Is(err, errInit) {
+					t.Errorf("%v is not the InitError of %s", err, c)
+				}
+			},
+		},
+		{
+			name: "config",
+			deps: []*Dep{
+				mustDep(NewFuncDep[errA](newErrA)),
+				mustDep(NewFuncDep[errB](newErrB)),
+				mustDep(NewDep[errC, errConfigImpl]()),
+			},
+			config: "[db]\nDSN = 1\n",
+			path:   []string{a, b, c},
+			check: func(t *testing.T, err error) {
+				var ce *ConfigError
+				if !errors.As(err, &ce) || ce.Section != "db" || ce.Key != "DSN" {
+					t.Errorf("%v is not the ConfigError of db.DSN", err)
+				}
+			},
+		},
+		{
+			name: "cycle",
+			deps: []*Dep{
+				mustDep(NewFuncDep[errA](newErrA)),
+				mustDep(NewFuncDep[errB](func(errA) (errB, error) { return struct{}{}, nil })),
+			},
+			path: []string{a, b},
+			check: func(t *testing.T, err error) {
+				var ce *CycleError
+				if !errors.As(err, &ce) || !slices.Equal(ce.Path, []string{a, b, a}) {
+					t.Errorf("%v is not the CycleError of %s -> %s -> %s", err, a, b, a)
+				}
+			},
+		},
+		{
+			name: "child",
+			deps: []*Dep{
+				mustDep(NewFuncDep[errA](newErrA)),
+				mustDep(NewFuncDep[errB](newErrB)),
+				mustDep(NewFuncDep[errC](newErrC)),
+			},
+			child: true,
+			path:  []string{a, b, c},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, errInit) {
+					t.Errorf("%v is not the error of %s", err, c)
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var rt Runtime = testRuntime(t, Config{Config: tc.config}, tc.deps...)
+			if tc.child {
+				// errA is constructed by the child, errB and errC by the
+				// parent.
+				child, err := rt.(Parent).Child(Overrides{Deps: []*Dep{mustDep(NewFuncDep[errA](newErrA))}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				rt = child
+			}
+
+			_, err := rt.GetIntf(Type[errA](), "")
+			var re *ResolveError
+			if !errors.As(err, &re) {
+				t.Fatalf("GetIntf() = %v, want a ResolveError", err)
+			}
+			if !slices.Equal(re.Path, tc.path) {
+				t.Errorf("path = %v, want %v", re.Path, tc.path)
+			}
+			tc.check(t, err)
+		})
+	}
+}
@@ -10,6 +10,8 @@ func init() {
 	deps.MustProvide[App, app]()
 }
 
+//go:generate go run github.com/cgfork/deps/deptest/cmd/depsmock -type App
+
 type App interface {
 	Display()
 }
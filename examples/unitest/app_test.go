@@ -31,4 +31,28 @@ func TestApp(t *testing.T) {
 	}, func(t *testing.T, app App) {
 		app.Display()
 	})
+	deptest.Test(t, deps.Config{
+		NamedFakes: []deps.Fake{deptest.Fake[App]("", &mock{})},
+	}, func(t *testing.T, app App) {
+		app.Display()
+	})
+}
+
+func TestAppMock(t *testing.T) {
+	m := NewMockApp(t)
+	m.Mock.On("Display").Times(1)
+	deptest.Test(t, deps.Config{
+		NamedFakes: []deps.Fake{deptest.Fake[App]("", m)},
+	}, func(t *testing.T, app App) {
+		app.Display()
+	})
+	if calls := m.Mock.Calls("Display"); len(calls) != 1 {
+		t.Errorf("Display called %d times, want 1", len(calls))
+	}
+}
+
+func TestAppReplay(t *testing.T) {
+	deptest.Test(t, deps.Config{}, func(t *testing.T, app App) {
+		app.Display()
+	}, deptest.Recorded[App]("", "testdata/app.json", NewMockApp(t)))
 }
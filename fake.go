@@ -0,0 +1,96 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Fake replaces the implementation of an interface with the given name by
+// Impl. See Config.NamedFakes.
+type Fake struct {
+	// Iface is the interface type.
+	Iface reflect.Type
+	// Name is the name of the faked implementation. Empty means the
+	// anonymous one.
+	Name string
+	// Impl is the fake, implementing Iface.
+	Impl any
+}
+
+// validateFakes checks that the fakes of the config implement their
+// interfaces, and that no implementation is faked twice by NamedFakes.
+func validateFakes(config Config) error {
+	var errs []error
+	check := func(iface reflect.Type, name string, impl any) {
+		switch {
+		case iface == nil:
+			errs = append(errs, fmt.Errorf("fake %q: missing interface", name))
+		case impl == nil:
+			errs = append(errs, fmt.Errorf("fake %v named %q is nil", iface, name))
+		case !reflect.TypeOf(impl).AssignableTo(iface):
+			errs = append(errs, fmt.Errorf("fake %v named %q: %T does not implement %v", iface, name, impl, iface))
+		}
+	}
+	for iface, impl := range config.Fakes {
+		check(iface, "", impl)
+	}
+	type key struct {
+		iface reflect.Type
+		name  string
+	}
+	seen := map[key]bool{}
+	for _, f := range config.NamedFakes {
+		check(f.Iface, f.Name, f.Impl)
+		k := key{f.Iface, f.Name}
+		if k.name == "" && k.iface != nil {
+			k.name = typeName(k.iface)
+		}
+		if seen[k] {
+			errs = append(errs, fmt.Errorf("fake %v named %q: duplicate", f.Iface, f.Name))
+		}
+		seen[k] = true
+	}
+	return errors.Join(errs...)
+}
+
+// validateNamedFakes checks that the named fakes replace registered
+// implementations, so that a misspelled name doesn't run the real one.
+func validateNamedFakes(fakes []Fake, depsByIntf map[reflect.Type]map[string]*Dep) error {
+	var errs []error
+	for _, f := range fakes {
+		name := f.Name
+		if name == "" {
+			name = typeName(f.Iface)
+		}
+		intfs, ok := depsByIntf[f.Iface]
+		if !ok {
+			errs = append(errs, fmt.Errorf("fake %v named %q: %w", f.Iface, f.Name, ErrNotRegistered))
+			continue
+		}
+		if _, ok := intfs[name]; !ok {
+			names := make([]string, 0, len(intfs))
+			for n := range intfs {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			errs = append(errs, fmt.Errorf("fake %v named %q: %w; the registered names are %q", f.Iface, f.Name, ErrNotRegistered, names))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fake returns the fake of dep, if any.
+func (r *runtime) fake(dep *Dep) (any, bool) {
+	for _, f := range r.config.NamedFakes {
+		if f.Iface != dep.iface {
+			continue
+		}
+		if f.Name == dep.name || f.Name == "" && dep.anonymous() {
+			return f.Impl, true
+		}
+	}
+	fake, ok := r.config.Fakes[dep.iface]
+	return fake, ok
+}
@@ -0,0 +1,119 @@
+package deps
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeFoo interface {
+	Foo() string
+}
+
+type fakeFooImpl struct {
+	Implements[fakeFoo]
+}
+
+func (f *fakeFooImpl) Foo() string { return "real" }
+
+type fakeFooA struct {
+	Implements[fakeFoo] `impl:"fooA"`
+}
+
+func (f *fakeFooA) Foo() string { return "realA" }
+
+type fakeFooFake string
+
+func (f fakeFooFake) Foo() string { return string(f) }
+
+func TestFakes(t *testing.T) {
+	iface := Type[fakeFoo]()
+	for _, tc := range []struct {
+		name      string
+		config    Config
+		anonymous string
+		fooA      string
+		err       string
+	}{
+		{
+			name:      "none",
+			anonymous: "real",
+			fooA:      "realA",
+		},
+		{
+			name:      "fakes",
+			config:    Config{Fakes: map[reflect.Type]any{iface: fakeFooFake("fake")}},
+			anonymous: "fake",
+			fooA:      "fake",
+		},
+		{
+			name: "named fakes",
+			config: Config{
+				Fakes:      map[reflect.Type]any{iface: fakeFooFake("fake")},
+				NamedFakes: []Fake{{Iface: iface, Name: "fooA", Impl: fakeFooFake("fakeA")}},
+			},
+			anonymous: "fake",
+			fooA:      "fakeA",
+		},
+		{
+			name:      "anonymous",
+			config:    Config{NamedFakes: []Fake{{Iface: iface, Impl: fakeFooFake("fake")}}},
+			anonymous: "fake",
+			fooA:      "realA",
+		},
+		{
+			name:   "not implemented",
+			config: Config{NamedFakes: []Fake{{Iface: iface, Name: "fooA", Impl: "fakeA"}}},
+			err:    "string does not implement",
+		},
+		{
+			name:   "nil",
+			config: Config{Fakes: map[reflect.Type]any{iface: nil}},
+			err:    "is nil",
+		},
+		{
+			name: "duplicate",
+			config: Config{NamedFakes: []Fake{
+				{Iface: iface, Impl: fakeFooFake("fake")},
+				{Iface: iface, Name: typeName(iface), Impl: fakeFooFake("fake")},
+			}},
+			err: "duplicate",
+		},
+		{
+			name:   "misspelled",
+			config: Config{NamedFakes: []Fake{{Iface: iface, Name: "fooa", Impl: fakeFooFake("fakeA")}}},
+			err:    `named "fooa": not registered`,
+		},
+		{
+			name:   "interface not registered",
+			config: Config{NamedFakes: []Fake{{Iface: Type[restartService](), Impl: fakeFooFake("fake")}}},
+			err:    "not registered",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newRuntime(context.Background(), []*Dep{
+				mustDep(NewDep[fakeFoo, fakeFooImpl]()),
+				mustDep(NewDep[fakeFoo, fakeFooA]()),
+			}, tc.config)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("newRuntime() = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range map[string]string{"": tc.anonymous, "fooA": tc.fooA} {
+				foo, err := r.GetIntf(iface, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := foo.(fakeFoo).Foo(); got != want {
+					t.Errorf("%q: Foo() = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DefaultCheckTimeout is the timeout applied to every health or readiness
+// check when Config.CheckTimeout is zero.
+const DefaultCheckTimeout = 5 * time.Second
+
+// HealthChecker is implemented by the implementations which are able to
+// report whether they are alive.
+type HealthChecker interface {
+	HealthCheck(context.Context) error
+}
+
+// ReadinessChecker is implemented by the implementations which are able to
+// report whether they are ready to serve.
+type ReadinessChecker interface {
+	ReadinessCheck(context.Context) error
+}
+
+// HealthReporter is implemented by the runtimes, and runs the checks of the
+// constructed implementations. See HealthHandler and ReadinessHandler.
+type HealthReporter interface {
+	// Health runs the health checks of the constructed implementations.
+	Health(context.Context) HealthStatus
+	// Readiness runs the readiness checks of the constructed implementations.
+	Readiness(context.Context) HealthStatus
+}
+
+// CheckResult is the result of a health or readiness check of a single
+// implementation.
+type CheckResult struct {
+	// Name is the id of the checked implementation.
+	Name string `json:"name"`
+	// Err is the error returned by the check, or nil if it passed.
+	Err error `json:"-"`
+	// Duration is the time spent in the check.
+	Duration time.Duration `json:"duration"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c CheckResult) MarshalJSON() ([]byte, error) {
+	type result struct {
+		Name     string `json:"name"`
+		Healthy  bool   `json:"healthy"`
+		Error    string `json:"error,omitempty"`
+		Duration string `json:"duration"`
+	}
+	res := result{Name: c.Name, Healthy: c.Err == nil, Duration: c.Duration.String()}
+	if c.Err != nil {
+		res.Error = c.Err.Error()
+	}
+	return json.Marshal(res)
+}
+
+// HealthStatus is the aggregated result of the checks of all the constructed
+// implementations.
+type HealthStatus struct {
+	// Healthy is true if all the checks passed.
+	Healthy bool `json:"healthy"`
+	// Components contains the per implementation results, sorted by name.
+	Components []CheckResult `json:"components"`
+}
+
+// Err returns the joined errors of the failed checks.
+func (s HealthStatus) Err() error {
+	var errs []error
+	for _, c := range s.Components {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Health runs the HealthCheck method of every constructed implementation.
+func (r *runtime) Health(ctx context.Context) HealthStatus {
+	return r.check(ctx, func(impl any) func(context.Context) error {
+		if c, ok := impl.(HealthChecker); ok {
+			return c.HealthCheck
+		}
+		return nil
+	})
+}
+
+// Readiness runs the ReadinessCheck method of every constructed implementation.
+func (r *runtime) Readiness(ctx context.Context) HealthStatus {
+	return r.check(ctx, func(impl any) func(context.Context) error {
+		if c, ok := impl.(ReadinessChecker); ok {
+			return c.ReadinessCheck
+		}
+		return nil
+	})
+}
+
+// check runs the checks selected by sel concurrently, each one bounded by
+// the configured timeout.
+func (r *runtime) check(ctx context.Context, sel func(any) func(context.Context) error) HealthStatus {
+	r.mu.Lock()
+	checks := map[string]func(context.Context) error{}
+	for name, impl := range r.impls {
+		if f := sel(impl); f != nil {
+			checks[name] = f
+		}
+	}
+	r.mu.Unlock()
+
+	timeout := r.config.CheckTimeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	results := make(chan CheckResult, len(checks))
+	for name, f := range checks {
+		go func(name string, f func(context.Context) error) {
+			results <- runCheck(ctx, name, timeout, f)
+		}(name, f)
+	}
+
+	status := HealthStatus{Healthy: true, Components: make([]CheckResult, 0, len(checks))}
+	for range checks {
+		res := <-results
+		if res.Err != nil {
+			status.Healthy = false
+		}
+		status.Components = append(status.Components, res)
+	}
+	sort.Slice(status.Components, func(i, j int) bool {
+		return status.Components[i].Name < status.Components[j].Name
+	})
+	return status
+}
+
+// runCheck runs f, giving up when the timeout expires even if f does not
+// respect the context.
+func runCheck(ctx context.Context, name string, timeout time.Duration, f func(context.Context) error) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- f(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check did not complete: %w", ctx.Err())
+	}
+	return CheckResult{Name: name, Err: err, Duration: time.Since(start)}
+}
+
+// HealthHandler returns a http.Handler reporting the aggregated health of
+// the runtime, suitable for a liveness probe. It responds with 200 if all
+// the checks passed and 503 otherwise. It panics if r doesn't implement
+// HealthReporter.
+func HealthHandler(r Runtime) http.Handler {
+	return statusHandler(capability[HealthReporter](r, "HealthHandler").Health)
+}
+
+// ReadinessHandler returns a http.Handler reporting the aggregated readiness
+// of the runtime, suitable for a readiness probe. It responds with 200 if all
+// the checks passed and 503 otherwise. It panics if r doesn't implement
+// HealthReporter.
+func ReadinessHandler(r Runtime) http.Handler {
+	return statusHandler(capability[HealthReporter](r, "ReadinessHandler").Readiness)
+}
+
+func statusHandler(check func(context.Context) HealthStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status := check(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if status.Healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
@@ -0,0 +1,190 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type checked interface{}
+
+// checker is a health and readiness checker. If hang is set, its health
+// check ignores the context, and blocks until released.
+type checker struct {
+	health, ready error
+	hang          chan struct{}
+}
+
+func (c *checker) HealthCheck(context.Context) error {
+	if c.hang != nil {
+		<-c.hang
+	}
+	return c.health
+}
+
+func (c *checker) ReadinessCheck(context.Context) error {
+	return c.ready
+}
+
+// checkedRuntime returns a runtime whose checkers, named by the keys of
+// checkers, are constructed.
+func checkedRuntime(t *testing.T, checkers map[string]*checker) *runtime {
+	t.Helper()
+	var deps []*Dep
+	for name, c := range checkers {
+		c := c
+		deps = append(deps, mustDep(NewFuncDep[checked](func() checked { return c }, WithName(name))))
+	}
+	r := testRuntime(t, Config{CheckTimeout: 20 * time.Millisecond}, deps...)
+	for name := range checkers {
+		if _, err := r.GetIntf(Type[checked](), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestHealth(t *testing.T) {
+	hang := make(chan struct{})
+	t.Cleanup(func() { close(hang) })
+	r := checkedRuntime(t, map[string]*checker{
+		"ok":   {},
+		"bad":  {health: errors.New("down"), ready: errors.New("warming up")},
+		"hang": {hang: hang},
+	})
+	id := func(name string) string { return typeName(Type[checked]()) + "$" + name }
+
+	for _, tc := range []struct {
+		name  string
+		check func(context.Context) HealthStatus
+		want  map[string]string // name to error, if any
+	}{
+		{
+			name:  "health",
+			check: r.Health,
+			want:  map[string]string{"bad": "down", "hang": "check did not complete: context deadline exceeded", "ok": ""},
+		},
+		{
+			name:  "readiness",
+			check: r.Readiness,
+			want:  map[string]string{"bad": "warming up", "hang": "", "ok": ""},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			status := tc.check(context.Background())
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("checks took %v, want them cut off by the timeout", d)
+			}
+			if status.Healthy {
+				t.Error("healthy, want unhealthy")
+			}
+			if len(status.Components) != len(tc.want) {
+				t.Fatalf("%d components, want %d", len(status.Components), len(tc.want))
+			}
+			for i, name := range []string{"bad", "hang", "ok"} { // sorted
+				c := status.Components[i]
+				if c.Name != id(name) {
+					t.Errorf("component %d = %q, want %q", i, c.Name, id(name))
+				}
+				got := ""
+				if c.Err != nil {
+					got = c.Err.Error()
+				}
+				if got != tc.want[name] {
+					t.Errorf("%s: error %q, want %q", name, got, tc.want[name])
+				}
+			}
+			if err := status.Err(); err == nil || !strings.Contains(err.Error(), id("bad")+": "+tc.want["bad"]) {
+				t.Errorf("Err() = %v, want the error of bad", err)
+			}
+		})
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		checkers map[string]*checker
+		handler  func(Runtime) http.Handler
+		code     int
+		errors   map[string]string // name to error, if any
+	}{
+		{
+			name:     "healthy",
+			checkers: map[string]*checker{"ok": {}},
+			handler:  HealthHandler,
+			code:     http.StatusOK,
+			errors:   map[string]string{"ok": ""},
+		},
+		{
+			name:     "unhealthy",
+			checkers: map[string]*checker{"ok": {}, "bad": {health: errors.New("down")}},
+			handler:  HealthHandler,
+			code:     http.StatusServiceUnavailable,
+			errors:   map[string]string{"ok": "", "bad": "down"},
+		},
+		{
+			name:     "ready",
+			checkers: map[string]*checker{"bad": {health: errors.New("down")}},
+			handler:  ReadinessHandler,
+			code:     http.StatusOK,
+			errors:   map[string]string{"bad": ""},
+		},
+		{
+			name:     "not ready",
+			checkers: map[string]*checker{"bad": {ready: errors.New("warming up")}},
+			handler:  ReadinessHandler,
+			code:     http.StatusServiceUnavailable,
+			errors:   map[string]string{"bad": "warming up"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := checkedRuntime(t, tc.checkers)
+			w := httptest.NewRecorder()
+			tc.handler(r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if w.Code != tc.code {
+				t.Errorf("status %d, want %d", w.Code, tc.code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("content type %q, want application/json", ct)
+			}
+
+			var body struct {
+				Healthy    bool
+				Components []struct {
+					Name     string
+					Healthy  bool
+					Error    string
+					Duration string
+				}
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Healthy != (tc.code == http.StatusOK) {
+				t.Errorf("healthy %v, want %v", body.Healthy, tc.code == http.StatusOK)
+			}
+			if len(body.Components) != len(tc.errors) {
+				t.Fatalf("%d components, want %d", len(body.Components), len(tc.errors))
+			}
+			for _, c := range body.Components {
+				name := strings.TrimPrefix(c.Name, typeName(Type[checked]())+"$")
+				want, ok := tc.errors[name]
+				switch {
+				case !ok:
+					t.Errorf("unexpected component %q", c.Name)
+				case c.Error != want || c.Healthy != (want == ""):
+					t.Errorf("%s: healthy %v, error %q, want %q", name, c.Healthy, c.Error, want)
+				case c.Duration == "":
+					t.Errorf("%s: missing duration", name)
+				}
+			}
+		})
+	}
+}
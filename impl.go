@@ -52,6 +52,7 @@ func GetIntf[T any](gr getRuntime, name string) (T, error) {
 }
 
 // GetImpl returns the object instance of the implementation T.
+// It fails if T is registered several times; use GetNamedImpl then.
 func GetImpl[T any](gr getRuntime) (*T, error) {
 	v, err := gr.xxx_getRuntime().GetImpl(Type[T]())
 	if err != nil {
@@ -60,6 +61,20 @@ func GetImpl[T any](gr getRuntime) (*T, error) {
 	return v.(*T), nil
 }
 
+// GetNamedImpl returns the object instance of the implementation T registered
+// with the specified name.
+func GetNamedImpl[T any](gr getRuntime, name string) (*T, error) {
+	r, ok := gr.xxx_getRuntime().(NamedResolver)
+	if !ok {
+		return nil, fmt.Errorf("the runtime %T does not resolve named implementations", gr.xxx_getRuntime())
+	}
+	v, err := r.GetNamedImpl(Type[T](), name)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*T), nil
+}
+
 func setupImpl(impl any, runtime Runtime) error {
 	x, ok := impl.(interface{ setRuntime(Runtime) })
 	if !ok {
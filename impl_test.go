@@ -0,0 +1,86 @@
+package deps
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type namedCache interface {
+	Addr() string
+}
+
+type namedCacheConfig struct {
+	Addr string
+}
+
+type namedCacheImpl struct {
+	Implements[namedCache]
+	WithConfig[namedCacheConfig]
+}
+
+func (c *namedCacheImpl) Addr() string { return c.Config().Addr }
+
+type namedApp interface{}
+
+type namedAppImpl struct {
+	Implements[namedApp]
+	a Ref[namedCache] `ref:"cacheA"`
+	b Ref[namedCache] `ref:"cacheB"`
+}
+
+func TestNamedImpls(t *testing.T) {
+	r := testRuntime(t, Config{Config: `
+[cacheA]
+addr = "a"
+
+[cacheB]
+addr = "b"
+`},
+		mustDep(NewDep[namedCache, namedCacheImpl](WithName("cacheA"), WithSection("cacheA"))),
+		mustDep(NewDep[namedCache, namedCacheImpl](WithName("cacheB"), WithSection("cacheB"))),
+		mustDep(NewDep[namedApp, namedAppImpl]()),
+	)
+	v, err := r.GetIntf(Type[namedApp](), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := v.(*namedAppImpl)
+	a, b := app.a.Get(), app.b.Get()
+	if a.Addr() != "a" || b.Addr() != "b" {
+		t.Errorf("addrs %q, %q; want a, b", a.Addr(), b.Addr())
+	}
+
+	// The implementation is ambiguous without a name.
+	if _, err := r.GetImpl(Type[namedCacheImpl]()); err == nil || !strings.Contains(err.Error(), "registered 2 times as [cacheA cacheB]; a name is required") {
+		t.Errorf("GetImpl() = %v, want the ambiguity error", err)
+	}
+	if _, err := GetImpl[namedCacheImpl](app); err == nil || !strings.Contains(err.Error(), "a name is required") {
+		t.Errorf("deps.GetImpl() = %v, want the ambiguity error", err)
+	}
+
+	for name, want := range map[string]namedCache{"cacheA": a, "cacheB": b} {
+		got, err := r.GetNamedImpl(Type[namedCacheImpl](), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("GetNamedImpl(%q) = %v, want the instance of the ref", name, got)
+		}
+		impl, err := GetNamedImpl[namedCacheImpl](app, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if impl != want {
+			t.Errorf("deps.GetNamedImpl(%q) = %v, want the instance of the ref", name, impl)
+		}
+	}
+	if _, err := r.GetNamedImpl(Type[namedCacheImpl](), "cacheC"); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("GetNamedImpl(cacheC) = %v, want ErrNotRegistered", err)
+	}
+
+	// A single registration needs no name.
+	if impl, err := GetImpl[namedAppImpl](app); err != nil || impl != app {
+		t.Errorf("deps.GetImpl() = %v, %v; want the app", impl, err)
+	}
+}
@@ -0,0 +1,179 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ModuleDef is a named group of related registrations, created by Module.
+// Unlike the registrations made by Provide, the ones of a module are not
+// global: the module is passed to a runtime explicitly with Config.Modules.
+//
+// The config sections of the deps of a module are prefixed with the name of
+// the module:
+//
+//	[storage.fooA]
+//	name = "abc"
+type ModuleDef struct {
+	name  string
+	items []ModuleItem
+}
+
+// ModuleItem is an element of a module: a registration or an included module.
+type ModuleItem interface {
+	collect(c *moduleCollector, module string)
+}
+
+// Module returns a module with the given name, grouping the given items.
+//
+//	var Storage = deps.Module("storage",
+//		deps.Provides[DB, db](),
+//		deps.ProvidesFunc[Cache](newCache),
+//		deps.Include(Metrics),
+//	)
+func Module(name string, items ...ModuleItem) *ModuleDef {
+	return &ModuleDef{name: name, items: items}
+}
+
+// Name returns the name of the module.
+func (m *ModuleDef) Name() string {
+	return m.name
+}
+
+// Deps returns the registrations of the module and of the modules it
+// includes.
+func (m *ModuleDef) Deps() ([]*Dep, error) {
+	c := &moduleCollector{seen: map[string]*ModuleDef{}}
+	m.collect(c, "")
+	return c.deps, errors.Join(c.errs...)
+}
+
+func (m *ModuleDef) collect(c *moduleCollector, _ string) {
+	if m.name == "" {
+		c.errs = append(c.errs, errors.New("module with empty name"))
+		return
+	}
+	if strings.ContainsAny(m.name, "./$") {
+		c.errs = append(c.errs, fmt.Errorf("module %q: name must not contain '.', '/' or '$'", m.name))
+		return
+	}
+	if old, ok := c.seen[m.name]; ok {
+		if old != m {
+			c.errs = append(c.errs, fmt.Errorf("multiple modules named %q", m.name))
+		}
+		return
+	}
+	c.seen[m.name] = m
+	for _, item := range m.items {
+		item.collect(c, m.name)
+	}
+}
+
+// Include returns an item including the given module into another one. A
+// module included several times is only registered once.
+func Include(m *ModuleDef) ModuleItem {
+	return m
+}
+
+// moduleCollector collects the registrations of modules.
+type moduleCollector struct {
+	seen map[string]*ModuleDef
+	deps []*Dep
+	errs []error
+}
+
+// registration is a ModuleItem registering a Dep.
+type registration struct {
+	dep Dep
+	err error
+}
+
+func (r registration) collect(c *moduleCollector, module string) {
+	if r.err != nil {
+		c.errs = append(c.errs, moduleError(module, r.err))
+		return
+	}
+	dep := r.dep
+	dep.module = module
+	if err := verifyDep(dep); err != nil {
+		c.errs = append(c.errs, moduleError(module, fmt.Errorf("Register(%q): %w", dep.name, err)))
+		return
+	}
+	c.deps = append(c.deps, &dep)
+}
+
+// moduleError returns err prefixed with the module, if any.
+func moduleError(module string, err error) error {
+	if module == "" {
+		return err
+	}
+	return fmt.Errorf("module %q: %w", module, err)
+}
+
+// Provides is the module counterpart of Provide.
+func Provides[Iface any, Impl any](opts ...Option) ModuleItem {
+	dep, err := NewDep[Iface, Impl](opts...)
+	return registration{dep, err}
+}
+
+// ProvidesFunc is the module counterpart of ProvideFunc.
+func ProvidesFunc[Iface any](fn any, opts ...Option) ModuleItem {
+	dep, err := NewFuncDep[Iface](fn, opts...)
+	return registration{dep, err}
+}
+
+// Supplies is the module counterpart of Supply.
+func Supplies[T any](value T, opts ...Option) ModuleItem {
+	dep, err := NewValueDep[T](value, opts...)
+	return registration{dep, err}
+}
+
+// withModules returns the deps with the ones of the modules of the config.
+func withModules(deps []*Dep, config Config) ([]*Dep, error) {
+	if len(config.Modules) == 0 {
+		return deps, nil
+	}
+	c := &moduleCollector{seen: map[string]*ModuleDef{}}
+	for _, m := range config.Modules {
+		m.collect(c, "")
+	}
+	if err := errors.Join(c.errs...); err != nil {
+		return nil, err
+	}
+	return append(append([]*Dep(nil), deps...), c.deps...), nil
+}
+
+// moduleSections returns the sections of the given module, found in the
+// table named after the module.
+func moduleSections(sections map[string]string, module string) (map[string]string, error) {
+	section, ok := sections[module]
+	if !ok {
+		return map[string]string{}, nil
+	}
+	subs, err := ParseTOML(section)
+	if err != nil {
+		return nil, fmt.Errorf("module %q config: %w", module, err)
+	}
+	return subs, nil
+}
+
+// sectionsOf returns the config sections in which the config of dep is
+// looked up.
+func (r *runtime) sectionsOf(dep *Dep) (map[string]string, error) {
+	if dep.module == "" {
+		return r.sections, nil
+	}
+	if subs, ok := r.modSections[dep.module]; ok {
+		return subs, nil
+	}
+	subs, err := moduleSections(r.sections, dep.module)
+	if err != nil {
+		return nil, err
+	}
+	if r.modSections == nil {
+		r.modSections = map[string]map[string]string{}
+	}
+	r.modSections[dep.module] = subs
+	return subs, nil
+}
@@ -0,0 +1,128 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type modDB interface {
+	DSN() string
+}
+
+type modDBConfig struct {
+	DSN string
+}
+
+type modDBImpl struct {
+	Implements[modDB]
+	WithConfig[modDBConfig]
+}
+
+func (d *modDBImpl) DSN() string { return d.Config().DSN }
+
+type modMetrics interface{}
+
+type modFailing interface{}
+
+func TestModuleSections(t *testing.T) {
+	storage := Module("storage", Provides[modDB, modDBImpl]())
+	r := testRuntime(t, Config{
+		Config: `
+["github.com/cgfork/deps.modDB"]
+dsn = "global"
+
+[storage."github.com/cgfork/deps.modDB"]
+dsn = "storage"
+`,
+		Modules: []*ModuleDef{storage},
+	})
+	db, err := r.GetIntf(Type[modDB](), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.(modDB).DSN(); got != "storage" {
+		t.Errorf("DSN() = %q, want the one of the storage section", got)
+	}
+
+	infos := r.Describe()
+	if len(infos) != 1 || infos[0].Module != "storage" {
+		t.Errorf("Describe() = %+v, want a dep of the storage module", infos)
+	}
+}
+
+func TestModuleInclude(t *testing.T) {
+	metrics := Module("metrics", ProvidesFunc[modMetrics](func() modMetrics { return struct{}{} }))
+	storage := Module("storage", Provides[modDB, modDBImpl](), Include(metrics))
+	api := Module("api", Include(metrics), Include(storage))
+
+	deps, err := api.Deps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, dep := range deps {
+		got = append(got, dep.label())
+	}
+	slices.Sort(got)
+	want := []string{"metrics/github.com/cgfork/deps.modMetrics", "storage/github.com/cgfork/deps.modDB"}
+	if !slices.Equal(got, want) {
+		t.Errorf("deps %v, want %v", got, want)
+	}
+
+	// The modules included several times are registered once.
+	testRuntime(t, Config{Modules: []*ModuleDef{storage, api, metrics}})
+}
+
+func TestModuleErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		modules []*ModuleDef
+		err     string
+	}{
+		{
+			name:    "empty name",
+			modules: []*ModuleDef{Module("")},
+			err:     "module with empty name",
+		},
+		{
+			name:    "invalid name",
+			modules: []*ModuleDef{Module("a.b")},
+			err:     `module "a.b": name must not contain '.', '/' or '$'`,
+		},
+		{
+			name:    "duplicate name",
+			modules: []*ModuleDef{Module("storage"), Module("api", Include(Module("storage")))},
+			err:     `multiple modules named "storage"`,
+		},
+		{
+			name:    "registration",
+			modules: []*ModuleDef{Module("storage", ProvidesFunc[modDB](42))},
+			err:     `module "storage": constructor int is not a function`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newRuntime(context.Background(), nil, Config{Modules: tc.modules})
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("newRuntime() = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestModuleLabel(t *testing.T) {
+	errFailed := errors.New("failed")
+	r := testRuntime(t, Config{Modules: []*ModuleDef{
+		Module("jobs", ProvidesFunc[modFailing](func() (modFailing, error) { return nil, errFailed })),
+	}})
+	_, err := r.GetIntf(Type[modFailing](), "")
+	var initErr *InitError
+	if !errors.As(err, &initErr) {
+		t.Fatalf("GetIntf() = %v, want an InitError", err)
+	}
+	if want := "jobs/github.com/cgfork/deps.modFailing"; initErr.Dep != want {
+		t.Errorf("InitError.Dep = %q, want %q", initErr.Dep, want)
+	}
+}
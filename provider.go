@@ -0,0 +1,223 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// argKind is the kind of an argument of a constructor function.
+type argKind int
+
+const (
+	argInvalid argKind = iota
+	argContext         // context.Context
+	argIntf            // a registered interface
+	argImpl            // a pointer to a registered implementation struct
+	argConfig          // a pointer to the config struct, unless it is supplied
+	argValue           // a supplied value
+)
+
+// providerArg describes an argument of a constructor function.
+type providerArg struct {
+	kind argKind
+	typ  reflect.Type
+}
+
+// providerArgs classifies the arguments of the constructor function type.
+func providerArgs(fn reflect.Type) []providerArg {
+	args := make([]providerArg, fn.NumIn())
+	for i := range args {
+		t := fn.In(i)
+		switch {
+		case t == Type[context.Context]():
+			args[i] = providerArg{argContext, t}
+		case t.Kind() == reflect.Interface:
+			args[i] = providerArg{argIntf, t}
+		case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct:
+			if _, ok := t.Elem().FieldByName("xxx_ifaceType"); ok {
+				args[i] = providerArg{argImpl, t.Elem()}
+			} else {
+				args[i] = providerArg{argConfig, t.Elem()}
+			}
+		case t.Kind() == reflect.Func || t.Kind() == reflect.Chan || t.Kind() == reflect.UnsafePointer:
+			args[i] = providerArg{argInvalid, t}
+		default:
+			args[i] = providerArg{argValue, t}
+		}
+	}
+	return args
+}
+
+// NewFuncDep returns the Dep of the implementation of Iface returned by the
+// constructor function fn. See ProvideFunc.
+func NewFuncDep[Iface any](fn any, opts ...Option) (Dep, error) {
+	iface, err := IfaceType[Iface]()
+	if err != nil {
+		return Dep{}, err
+	}
+
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || fv.IsNil() {
+		return Dep{}, fmt.Errorf("constructor %T is not a function", fn)
+	}
+	ft := fv.Type()
+	if ft.IsVariadic() {
+		return Dep{}, fmt.Errorf("constructor %v must not be variadic", ft)
+	}
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) == iface:
+	case ft.NumOut() == 2 && ft.Out(0) == iface && ft.Out(1) == Type[error]():
+	default:
+		return Dep{}, fmt.Errorf("constructor %v must return %v or (%v, error)", ft, iface, iface)
+	}
+
+	for i, arg := range providerArgs(ft) {
+		if arg.kind == argInvalid {
+			return Dep{}, fmt.Errorf("constructor %v argument %d has unsupported type %v", ft, i, arg.typ)
+		}
+	}
+
+	dep := Dep{iface: iface, provider: fv}
+	for _, o := range opts {
+		o(&dep)
+	}
+
+	dep.initNames()
+	return dep, nil
+}
+
+// ProvideFunc registers the constructor function fn as the implementation of
+// Iface, so that types which can't embed deps.Implements, like the ones of
+// third party packages, can take part in the graph. fn must look like:
+//
+//	func(ctx context.Context, cfg *C, a A, b *B) (Iface, error)
+//
+// Its arguments, in any order and all optional, are resolved as follows:
+//   - a context.Context gets the runtime context;
+//   - an interface gets its anonymous registered implementation;
+//   - a pointer to a struct embedding deps.Implements gets that implementation;
+//   - a type registered with Supply gets the supplied value;
+//   - a pointer to any other struct gets the config, decoded from the section
+//     named after the dep, or set by WithSection, like deps.WithConfig. There
+//     must be at most one.
+//
+// The error result is optional. Init is not called on the returned value; the
+// runtime shuts it down with its Shutdown or Close method, if any.
+func ProvideFunc[Iface any](fn any, opts ...Option) error {
+	dep, err := NewFuncDep[Iface](fn, opts...)
+	if err != nil {
+		return err
+	}
+	return globalRegistry.register(dep)
+}
+
+// MustProvideFunc is like ProvideFunc but panics on error.
+func MustProvideFunc[Iface any](fn any, opts ...Option) {
+	if err := ProvideFunc[Iface](fn, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// call calls the constructor function of dep.
+func (r *runtime) call(dep *Dep, timing *InitTiming) (any, error) {
+	ft := dep.provider.Type()
+	pargs := r.providerArgs(dep)
+	if configArgs(pargs) > 1 {
+		return nil, fmt.Errorf("constructor of %q has more than one config argument", dep.label())
+	}
+	args := make([]reflect.Value, ft.NumIn())
+	for i, arg := range pargs {
+		switch arg.kind {
+		case argContext:
+			args[i] = reflect.ValueOf(r.ctx)
+		case argConfig:
+			cfg := reflect.New(arg.typ)
+			if err := timed(&timing.Config, func() error {
+				sections, err := r.sectionsOf(dep)
+				if err != nil {
+					return err
+				}
+				return loadConfig(dep.name, dep.section, sections, cfg.Interface())
+			}); err != nil {
+				return nil, err
+			}
+			args[i] = cfg
+		case argIntf, argImpl, argValue:
+			var v any
+			if err := timed(&timing.Refs, func() error {
+				ref, err := r.resolveRef(refField{typ: arg.typ, impl: arg.kind == argImpl}, dep.name)
+				if err != nil {
+					return err
+				}
+				if arg.kind == argImpl {
+					v, err = r.get(ref)
+				} else {
+					v, err = r.getDep(ref, dep.name)
+				}
+				if err != nil {
+					return err
+				}
+				r.addEdge(dep, ref)
+				return nil
+			}); err != nil {
+				return nil, fmt.Errorf("constructor of %q argument %d: %w", dep.label(), i, err)
+			}
+			args[i] = reflect.ValueOf(v)
+		default:
+			return nil, fmt.Errorf("constructor of %q argument %d has unsupported type %v", dep.label(), i, arg.typ)
+		}
+	}
+
+	var out []reflect.Value
+	_ = timed(&timing.Init, func() error {
+		out = dep.provider.Call(args)
+		return nil
+	})
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, &InitError{Dep: dep.label(), Err: out[1].Interface().(error)}
+	}
+	if out[0].IsNil() {
+		return nil, fmt.Errorf("constructor of %q returned nil", dep.label())
+	}
+	return out[0].Interface(), nil
+}
+
+// providerArgs classifies the arguments of the constructor function of dep,
+// taking the supplied values into account.
+func (r *runtime) providerArgs(dep *Dep) []providerArg {
+	return resolveArgs(providerArgs(dep.provider.Type()), func(t reflect.Type) bool {
+		_, ok := r.depsByIntf[t]
+		return ok
+	})
+}
+
+// resolveArgs turns the config arguments whose type is registered into
+// supplied value arguments.
+func resolveArgs(args []providerArg, registered func(reflect.Type) bool) []providerArg {
+	for i, arg := range args {
+		if arg.kind == argConfig && registered(reflect.PointerTo(arg.typ)) {
+			args[i] = providerArg{argValue, reflect.PointerTo(arg.typ)}
+		}
+	}
+	return args
+}
+
+// configArgs returns the number of config arguments.
+func configArgs(args []providerArg) int {
+	n := 0
+	for _, arg := range args {
+		if arg.kind == argConfig {
+			n++
+		}
+	}
+	return n
+}
+
+// resolveRef returns the registration referred by ref in the requester.
+func (r *runtime) resolveRef(ref refField, requester string) (*Dep, error) {
+	if ref.impl {
+		return r.resolveImpl(ref.typ, ref.name)
+	}
+	return r.resolveIntf(ref.typ, ref.name, requester)
+}
@@ -0,0 +1,154 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type provIface interface{}
+type provLogger interface{}
+type provStore interface{}
+
+type provLoggerImpl struct {
+	Implements[provLogger]
+}
+
+type provStoreImpl struct {
+	Implements[provStore]
+}
+
+type provConfig struct {
+	Addr string
+}
+
+type provOtherConfig struct {
+	Port int
+}
+
+type provClient struct {
+	Timeout int
+}
+
+// provResult is the value returned by the constructor functions, recording
+// their arguments.
+type provResult struct {
+	ctx    context.Context
+	cfg    *provConfig
+	logger provLogger
+	store  *provStoreImpl
+	client *provClient
+	closed bool
+}
+
+func (p *provResult) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestProvideFunc(t *testing.T) {
+	client := &provClient{Timeout: 3}
+	newResult := func(ctx context.Context, cfg *provConfig, logger provLogger, store *provStoreImpl, client *provClient) (provIface, error) {
+		return &provResult{ctx: ctx, cfg: cfg, logger: logger, store: store, client: client}, nil
+	}
+	for _, tc := range []struct {
+		name   string
+		opts   []Option
+		config string
+	}{
+		{"dep section", nil, "[\"github.com/cgfork/deps.provIface\"]\naddr = \"dep section\"\n"},
+		{"named dep section", []Option{WithName("named")}, "[named]\naddr = \"named dep section\"\n"},
+		{"with section", []Option{WithSection("custom")}, "[custom]\naddr = \"with section\"\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := mustDep(NewFuncDep[provIface](newResult, tc.opts...))
+			r, err := newRuntime(context.Background(), []*Dep{
+				dep,
+				mustDep(NewDep[provLogger, provLoggerImpl]()),
+				mustDep(NewDep[provStore, provStoreImpl]()),
+				mustDep(NewValueDep(client)),
+			}, Config{Config: tc.config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := r.GetIntf(Type[provIface](), dep.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := v.(*provResult)
+			if res.ctx != r.Context() {
+				t.Error("ctx is not the runtime context")
+			}
+			if res.cfg.Addr != tc.name {
+				t.Errorf("config addr %q, want %q", res.cfg.Addr, tc.name)
+			}
+			if logger, _ := r.GetIntf(Type[provLogger](), ""); res.logger != logger {
+				t.Errorf("logger %v, want %v", res.logger, logger)
+			}
+			if store, _ := r.GetImpl(Type[provStoreImpl]()); res.store != store {
+				t.Errorf("store %v, want %v", res.store, store)
+			}
+			if res.client != client {
+				t.Errorf("client %v, want the supplied one", res.client)
+			}
+
+			if err := r.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if !res.closed {
+				t.Error("not closed on shutdown")
+			}
+		})
+	}
+}
+
+func TestNewFuncDepErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		fn   any
+		err  string
+	}{
+		{"not a function", 42, "is not a function"},
+		{"nil", (func() provIface)(nil), "is not a function"},
+		{"variadic", func(...int) provIface { return nil }, "must not be variadic"},
+		{"bad return", func() string { return "" }, "must return"},
+		{"bad second return", func() (provIface, int) { return nil, 0 }, "must return"},
+		{"unsupported argument", func(chan int) provIface { return nil }, "argument 0 has unsupported type"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewFuncDep[provIface](tc.fn); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("NewFuncDep() = %v, want %q", err, tc.err)
+			}
+		})
+	}
+	// ProvideFunc reports the same errors, and registers nothing.
+	if err := ProvideFunc[provIface](42); err == nil || !strings.Contains(err.Error(), "is not a function") {
+		t.Errorf("ProvideFunc() = %v, want the error of NewFuncDep", err)
+	}
+}
+
+func TestProvideFuncCallErrors(t *testing.T) {
+	errFailed := errors.New("failed")
+	for _, tc := range []struct {
+		name string
+		fn   any
+		err  string
+	}{
+		{"two configs", func(*provConfig, *provOtherConfig) provIface { return &provResult{} }, "more than one config argument"},
+		{"nil", func() provIface { return nil }, "returned nil"},
+		{"failed", func() (provIface, error) { return nil, errFailed }, "initialization failed: failed"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testRuntime(t, Config{}, mustDep(NewFuncDep[provIface](tc.fn)))
+			_, err := r.GetIntf(Type[provIface](), "")
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("GetIntf() = %v, want %q", err, tc.err)
+			}
+			var initErr *InitError
+			if errors.As(err, &initErr) != (tc.name == "failed") {
+				t.Errorf("GetIntf() = %v, InitError %v", err, initErr)
+			}
+		})
+	}
+}
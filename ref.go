@@ -3,6 +3,7 @@ package deps
 import (
 	"fmt"
 	"reflect"
+	"slices"
 )
 
 // Ref[T] is a field that can be placed inside an implementation
@@ -61,3 +62,55 @@ func setupRefs(impl any, get func(reflect.Type, string) (any, error)) error {
 	}
 	return nil
 }
+
+// refField describes a deps.Ref[T] field of an implementation struct, or
+// an argument of a constructor function.
+type refField struct {
+	field reflect.StructField
+	typ   reflect.Type // T
+	name  string       // the ref tag
+	impl  bool         // typ is a pointer to an implementation struct
+}
+
+// depRefs returns the references of the dep.
+func depRefs(dep *Dep) []refField {
+	if dep.provider.IsValid() {
+		var refs []refField
+		for _, arg := range providerArgs(dep.provider.Type()) {
+			switch arg.kind {
+			case argIntf, argValue:
+				refs = append(refs, refField{typ: arg.typ})
+			case argImpl:
+				refs = append(refs, refField{typ: arg.typ, impl: true})
+			}
+		}
+		return refs
+	}
+	if dep.impl == nil {
+		// A supplied value has no refs.
+		return nil
+	}
+	refs := refFields(dep.impl)
+	if dep.decorator {
+		// The refs to the decorated interface are set to the inner instance.
+		refs = slices.DeleteFunc(refs, func(ref refField) bool { return ref.typ == dep.iface })
+	}
+	return refs
+}
+
+// refFields returns the deps.Ref[T] fields of the implementation struct.
+func refFields(impl reflect.Type) []refField {
+	var refs []refField
+	for i := 0; i < impl.NumField(); i++ {
+		f := impl.Field(i)
+		if !f.Type.Implements(Type[interface{ isRef() }]()) {
+			continue
+		}
+		refs = append(refs, refField{
+			field: f,
+			typ:   f.Type.Field(0).Type, // a Ref[T]'s value field
+			name:  f.Tag.Get("ref"),
+		})
+	}
+	return refs
+}
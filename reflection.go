@@ -30,3 +30,15 @@ func StructType[T any]() (reflect.Type, error) {
 	}
 	return implType, nil
 }
+
+// typeName returns the full package-prefixed name of the type, like
+// "github.com/cgfork/deps.System" or "*net/http.Client".
+func typeName(t reflect.Type) string {
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	if t.Kind() == reflect.Pointer {
+		return "*" + typeName(t.Elem())
+	}
+	return t.String()
+}
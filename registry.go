@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"sync"
 )
 
@@ -36,7 +37,9 @@ func (r *registry) register(dep Dep) error {
 
 	r.m.Lock()
 	defer r.m.Unlock()
-	if old, ok := r.byId[dep.id]; ok {
+	// Conditional registrations may share their id, as long as only one of
+	// them is active in a runtime.
+	if old, ok := r.byId[dep.id]; ok && !old.conditional() && !dep.conditional() {
 		return fmt.Errorf("dep %s already registered for type %v when registering %v",
 			dep.name, old.impl, dep.impl)
 	}
@@ -49,13 +52,23 @@ func (r *registry) register(dep Dep) error {
 		r.byId = map[string]*Dep{}
 	}
 
-	if dep.singleton && len(r.deps[dep.iface]) > 0 {
-		return fmt.Errorf("dep %s already registered when registering %v", dep.name, dep.impl)
+	olds := slices.DeleteFunc(slices.Clone(r.deps[dep.iface]), func(d *Dep) bool { return d.decorator })
+	if len(olds) > 0 && !dep.decorator && !dep.conditional() && !olds[0].conditional() {
+		if dep.singleton {
+			return fmt.Errorf("dep %s is single-implementation but %v already registered when registering %v",
+				dep.name, dep.iface, dep.implType())
+		}
+		if olds[0].singleton {
+			return fmt.Errorf("dep %s is single-implementation, already registered for type %v when registering %v",
+				olds[0].name, olds[0].implType(), dep.implType())
+		}
 	}
 
 	ptr := &dep
 	r.deps[dep.iface] = append(r.deps[dep.iface], ptr)
-	r.byId[dep.id] = ptr
+	if _, ok := r.byId[dep.id]; !ok {
+		r.byId[dep.id] = ptr
+	}
 	return nil
 }
 
@@ -63,14 +76,26 @@ func verifyDep(dep Dep) error {
 	if dep.iface == nil {
 		return errors.New("missing dep type")
 	}
-	if dep.iface.Kind() != reflect.Interface {
+	if dep.value.IsValid() {
+		if dep.value.Type() != dep.iface {
+			return errors.New("supplied value type does not match the dep type")
+		}
+		if dep.impl != nil || dep.provider.IsValid() {
+			return errors.New("both supplied value and implementation set")
+		}
+	} else if dep.iface.Kind() != reflect.Interface {
 		return errors.New("dep type is not an interface")
-	}
-	if dep.impl == nil {
-		return errors.New("missing implementation type")
-	}
-	if dep.impl.Kind() != reflect.Struct {
-		return errors.New("implementation type is not a struct")
+	} else if dep.provider.IsValid() {
+		if dep.impl != nil {
+			return errors.New("both implementation type and constructor set")
+		}
+	} else {
+		if dep.impl == nil {
+			return errors.New("missing implementation type")
+		}
+		if dep.impl.Kind() != reflect.Struct {
+			return errors.New("implementation type is not a struct")
+		}
 	}
 
 	if dep.id == "" {
@@ -103,6 +128,98 @@ func (r *registry) search(typ reflect.Type) ([]*Dep, bool) {
 	return regs, ok
 }
 
+// Registry is a set of registrations, like the global one of Provide. It
+// allows, for instance, tests to use their own registrations without
+// interfering with each other.
+type Registry struct {
+	r registry
+}
+
+// NewRegistry returns a registry containing the given registrations, like
+// the ones returned by Registered.
+func NewRegistry(deps ...*Dep) (*Registry, error) {
+	r := &Registry{}
+	for _, dep := range deps {
+		if err := r.r.register(*dep); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Add registers the registrations of the given items, made by Provides,
+// ProvidesFunc, Supplies, Decorates or Include, with the same checks as
+// Provide. Unlike the registrations of a module included in a runtime, the
+// registrations of the items are not prefixed with a module name.
+func (r *Registry) Add(items ...ModuleItem) error {
+	c := &moduleCollector{seen: map[string]*ModuleDef{}}
+	for _, item := range items {
+		item.collect(c, "")
+	}
+	if err := errors.Join(c.errs...); err != nil {
+		return err
+	}
+	for _, dep := range c.deps {
+		if err := r.r.register(*dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes the registrations of the interface iface with the given
+// names, an empty name meaning the anonymous implementation, or all of its
+// implementations if no name is given. The decorators of iface are only
+// removed if their names are given.
+func (r *Registry) Remove(iface reflect.Type, names ...string) {
+	r.r.remove(iface, names)
+}
+
+// Deps returns the registrations.
+func (r *Registry) Deps() []*Dep {
+	return r.r.alldeps()
+}
+
+func (r *registry) remove(iface reflect.Type, names []string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var kept, removed []*Dep
+	for _, dep := range r.deps[iface] {
+		if dep.removedBy(names) {
+			removed = append(removed, dep)
+		} else {
+			kept = append(kept, dep)
+		}
+	}
+	if len(kept) == 0 {
+		delete(r.deps, iface)
+	} else {
+		r.deps[iface] = kept
+	}
+	for _, dep := range removed {
+		if r.byId[dep.id] == dep {
+			delete(r.byId, dep.id)
+		}
+	}
+	// Another conditional registration may share the id of a removed one.
+	for _, dep := range r.deps[iface] {
+		if _, ok := r.byId[dep.id]; !ok {
+			r.byId[dep.id] = dep
+		}
+	}
+}
+
+// removedBy returns true if the dep is removed by Remove with the given
+// names.
+func (d *Dep) removedBy(names []string) bool {
+	if len(names) == 0 {
+		return !d.decorator
+	}
+	return slices.ContainsFunc(names, func(name string) bool {
+		return name == d.name || name == "" && !d.decorator && d.anonymous()
+	})
+}
+
 func Provide[Iface any, Impl any](opts ...Option) error {
 	dep, err := NewDep[Iface, Impl](opts...)
 	if err != nil {
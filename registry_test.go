@@ -0,0 +1,104 @@
+package deps
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type regFoo interface{}
+
+type regFooImpl struct {
+	Implements[regFoo]
+}
+
+type regFooA struct {
+	Implements[regFoo] `impl:"fooA"`
+}
+
+type regFooDecorator struct {
+	Implements[regFoo]
+	inner Ref[regFoo]
+}
+
+func TestRegistryRemove(t *testing.T) {
+	anonymous := typeName(Type[regFoo]())
+	decorator := typeName(Type[regFooDecorator]())
+	for _, tc := range []struct {
+		name  string
+		names []string
+		want  []string // the sorted names of the remaining deps
+	}{
+		{"all", nil, []string{decorator}},
+		{"named", []string{"fooA"}, []string{anonymous, decorator}},
+		{"anonymous", []string{""}, []string{"fooA", decorator}},
+		{"decorator", []string{decorator}, []string{"fooA", anonymous}},
+		{"unknown", []string{"fooB"}, []string{"fooA", anonymous, decorator}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reg, err := NewRegistry()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := reg.Add(
+				Provides[regFoo, regFooImpl](),
+				Provides[regFoo, regFooA](),
+				Decorates[regFoo, regFooDecorator](),
+			); err != nil {
+				t.Fatal(err)
+			}
+			reg.Remove(Type[regFoo](), tc.names...)
+
+			var got []string
+			for _, dep := range reg.Deps() {
+				got = append(got, dep.Name())
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("remaining deps %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegistryAdd(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		items []ModuleItem
+		err   string
+	}{
+		{
+			name:  "named implementations",
+			items: []ModuleItem{Provides[regFoo, regFooImpl](), Provides[regFoo, regFooA]()},
+		},
+		{
+			name:  "duplicate",
+			items: []ModuleItem{Provides[regFoo, regFooA](), Provides[regFoo, regFooA]()},
+			err:   "already registered",
+		},
+		{
+			name:  "single implementation",
+			items: []ModuleItem{Provides[regFoo, regFooImpl](WithSingleton()), Provides[regFoo, regFooA]()},
+			err:   "single-implementation",
+		},
+		{
+			name: "single implementation decorated",
+			items: []ModuleItem{
+				Provides[regFoo, regFooImpl](WithSingleton()),
+				Decorates[regFoo, regFooDecorator](),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reg, err := NewRegistry()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = reg.Add(tc.items...)
+			if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Errorf("Add() = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
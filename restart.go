@@ -0,0 +1,156 @@
+package deps
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartMode tells whether a failed background service is restarted.
+type RestartMode string
+
+const (
+	// RestartNever never restarts the service; its failure stops the system.
+	RestartNever RestartMode = "never"
+	// RestartOnFailure restarts the service when Serve returns an error.
+	RestartOnFailure RestartMode = "on-failure"
+)
+
+const (
+	// DefaultRestartBackoff is the delay before the first restart when
+	// RestartPolicy.Backoff is zero.
+	DefaultRestartBackoff = 100 * time.Millisecond
+	// DefaultMaxRestartBackoff caps the delay between restarts when
+	// RestartPolicy.MaxBackoff is zero.
+	DefaultMaxRestartBackoff = time.Minute
+)
+
+// RestartPolicy is the restart policy of the background service of an
+// implementation. See Server.
+//
+// It is set with the WithRestart option, or in the config:
+//
+//	[deps.restart."main.Consumer"]
+//	mode = "on-failure"
+//	backoff = "1s"
+//	max_backoff = "1m"
+//	max_restarts = 5
+//	window = "10m"
+//
+// The config takes precedence over the option.
+type RestartPolicy struct {
+	// Mode is the restart mode. Empty means RestartNever.
+	Mode RestartMode `toml:"mode"`
+	// Backoff is the delay before the first restart. It is doubled for
+	// every restart within Window, up to MaxBackoff. Zero means
+	// DefaultRestartBackoff.
+	Backoff time.Duration `toml:"backoff"`
+	// MaxBackoff caps the delay between restarts. Zero means
+	// DefaultMaxRestartBackoff, or Backoff if it is greater.
+	MaxBackoff time.Duration `toml:"max_backoff"`
+	// MaxRestarts is the maximum number of restarts within Window; once
+	// exceeded, the failure stops the system. Zero means no limit.
+	MaxRestarts int `toml:"max_restarts"`
+	// Window is the period in which the restarts are counted. Zero means
+	// the whole life of the runtime.
+	Window time.Duration `toml:"window"`
+}
+
+// Validate validates the policy.
+func (p *RestartPolicy) Validate() error {
+	switch p.Mode {
+	case "", RestartNever, RestartOnFailure:
+	default:
+		return fmt.Errorf("unknown restart mode %q", p.Mode)
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 || p.Window < 0 || p.MaxRestarts < 0 {
+		return fmt.Errorf("negative restart policy value")
+	}
+	return nil
+}
+
+// withDefaults returns the policy with the default values of the unset
+// delays.
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.Backoff == 0 {
+		p.Backoff = DefaultRestartBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = max(DefaultMaxRestartBackoff, p.Backoff)
+	}
+	return p
+}
+
+// delay returns the delay before a restart following n restarts within the
+// window.
+func (p RestartPolicy) delay(n int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < n && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
+
+// WithRestart sets the restart policy of the background service of the Dep.
+func WithRestart(policy RestartPolicy) Option {
+	return func(dep *Dep) {
+		dep.restart = &policy
+	}
+}
+
+// restartPolicy returns the restart policy of dep.
+func (r *runtime) restartPolicy(dep *Dep) RestartPolicy {
+	if p, ok := r.rtConfig.Restart[dep.name]; ok {
+		return p
+	}
+	if dep.restart != nil {
+		return *dep.restart
+	}
+	return RestartPolicy{Mode: RestartNever}
+}
+
+// supervise runs s.Serve, restarting it according to the policy. It returns
+// the error which must stop the system, or nil.
+func (r *runtime) supervise(dep *Dep, s Server) error {
+	policy := r.restartPolicy(dep).withDefaults()
+	var (
+		recent   []time.Time // the times of the restarts within the window, if any
+		restarts int         // the number of restarts within the window
+	)
+	for {
+		err := s.Serve(r.ctx)
+		if err == nil || r.ctx.Err() != nil {
+			return err
+		}
+		if policy.Mode != RestartOnFailure {
+			return err
+		}
+
+		now := time.Now()
+		if policy.Window > 0 {
+			i := 0
+			for i < len(recent) && now.Sub(recent[i]) > policy.Window {
+				i++
+			}
+			recent = recent[i:]
+			restarts = len(recent)
+		}
+		if policy.MaxRestarts > 0 && restarts >= policy.MaxRestarts {
+			return fmt.Errorf("%w (gave up after %d restarts)", err, restarts)
+		}
+
+		delay := policy.delay(restarts)
+		if policy.Window > 0 {
+			recent = append(recent, now)
+		}
+		restarts++
+		count := r.servers.restarted(dep.id)
+		r.config.Root.Warn("restarting background service",
+			"dep", dep.label(), "err", err, "delay", delay, "restarts", count)
+
+		select {
+		case <-time.After(delay):
+		case <-r.ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyDelay(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy RestartPolicy
+		n      int
+		want   time.Duration
+	}{
+		{"default first", RestartPolicy{}, 0, DefaultRestartBackoff},
+		{"default doubled", RestartPolicy{}, 2, 4 * DefaultRestartBackoff},
+		{"default capped", RestartPolicy{}, 1000, DefaultMaxRestartBackoff},
+		{"backoff", RestartPolicy{Backoff: time.Second}, 1, 2 * time.Second},
+		{"max backoff", RestartPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second}, 5, 3 * time.Second},
+		{"backoff above default cap", RestartPolicy{Backoff: 2 * time.Minute}, 3, 2 * time.Minute},
+		{"max backoff below backoff", RestartPolicy{Backoff: time.Second, MaxBackoff: time.Millisecond}, 0, time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.policy.withDefaults().delay(tc.n); got != tc.want {
+				t.Errorf("delay(%d) = %v, want %v", tc.n, got, tc.want)
+			}
+		})
+	}
+}
+
+type restartService interface{}
+
+// flakyServer fails the given number of times, then serves until the
+// runtime is shut down.
+type flakyServer struct {
+	fails  int
+	served chan struct{}
+}
+
+func (s *flakyServer) Serve(ctx context.Context) error {
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("flaky")
+	}
+	close(s.served)
+	<-ctx.Done()
+	return nil
+}
+
+func TestSupervise(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fails    int
+		policy   RestartPolicy
+		restarts int
+		err      string // the error stopping the system, if any
+	}{
+		{
+			name:   "never",
+			fails:  1,
+			policy: RestartPolicy{},
+			err:    "flaky",
+		},
+		{
+			name:     "restarted",
+			fails:    2,
+			policy:   RestartPolicy{Mode: RestartOnFailure, Backoff: time.Millisecond, MaxRestarts: 3},
+			restarts: 2,
+		},
+		{
+			name:     "gave up",
+			fails:    3,
+			policy:   RestartPolicy{Mode: RestartOnFailure, Backoff: time.Millisecond, MaxRestarts: 2},
+			restarts: 2,
+			err:      "gave up after 2 restarts",
+		},
+		{
+			name:     "window",
+			fails:    3,
+			policy:   RestartPolicy{Mode: RestartOnFailure, Backoff: time.Millisecond, MaxRestarts: 1, Window: time.Microsecond},
+			restarts: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &flakyServer{fails: tc.fails, served: make(chan struct{})}
+			dep := mustDep(NewFuncDep[restartService](func() (restartService, error) {
+				return s, nil
+			}, WithRestart(tc.policy)))
+			r, err := newRuntime(context.Background(), []*Dep{dep}, Config{
+				Root: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.GetIntf(Type[restartService](), ""); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-s.served:
+			case <-r.ctx.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout")
+			}
+			err = r.Shutdown(context.Background())
+			switch {
+			case tc.err == "" && err != nil:
+				t.Errorf("Shutdown() = %v, want nil", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Errorf("Shutdown() = %v, want %q", err, tc.err)
+			}
+			if got := r.servers.restartCount(dep.id); got != tc.restarts {
+				t.Errorf("%d restarts, want %d", got, tc.restarts)
+			}
+		})
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"reflect"
+	"slices"
+	"sort"
+	"syscall"
 )
 
 // System is the interface implemented by a deps system which is started by the
@@ -20,10 +25,32 @@ type PointerToSystem[T any] interface {
 	InstanceOf[System]
 }
 
-// Run starts a deps system.
+// Run starts a deps system: it constructs T and calls start with it, then
+// shuts the system down once start returns.
+//
+// The context passed to the components and to start is canceled when ctx is
+// canceled, or a background service fails. Once start returns, the
+// background services are stopped and the constructed implementations are
+// shut down within Config.ShutdownTimeout. The returned error joins the
+// error of start, unless it is the cancellation of the context, the error of
+// the failed service and the shutdown errors.
 func Run[T any, P PointerToSystem[T]](ctx context.Context, config Config, start func(context.Context, *T) error) error {
+	return run[T](ctx, config, start, func() {})
+}
+
+// RunUntilSignal is like Run, but also cancels the context when the process
+// receives SIGINT or SIGTERM. The signals are handled until start returns.
+func RunUntilSignal[T any, P PointerToSystem[T]](ctx context.Context, config Config, start func(context.Context, *T) error) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return run[T](ctx, config, start, stop)
+}
+
+// run implements Run. stop is called once start returns, before the
+// shutdown.
+func run[T any](ctx context.Context, config Config, start func(context.Context, *T) error, stop func()) error {
 	regs := Registered()
-	if err := ValidateDeps(regs); err != nil {
+	if _, err := ValidateDepsWithConfig(regs, config); err != nil {
 		return err
 	}
 
@@ -32,44 +59,163 @@ func Run[T any, P PointerToSystem[T]](ctx context.Context, config Config, start
 		return err
 	}
 
-	sys, err := r.GetImpl(Type[T]())
-	if err != nil {
-		return err
+	var startErr error
+	if sys, err := r.GetImpl(Type[T]()); err != nil {
+		startErr = err
+	} else {
+		startErr = start(r.ctx, sys.(*T))
 	}
-	return start(ctx, sys.(*T))
+	if errors.Is(startErr, context.Canceled) && r.ctx.Err() != nil {
+		// Stopped by ctx, the signal or a failed background service.
+		startErr = nil
+	}
+	stop()
+
+	timeout := config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	return errors.Join(startErr, r.Shutdown(sctx))
 }
 
 // ValidateDeps validates the given registrations.
-// It makes sure that every type which is refered by the runtime.Ref field of the impl type
-// has been registered.
+// It makes sure that every type which is refered by the runtime.Ref field of the impl type,
+// or by an argument of a constructor function, has been registered or supplied, that
+// the interfaces marked with WithSingleton have a single implementation, that the
+// decorated interfaces have been registered, and that the singletons don't refer to
+// Scoped deps. The conditional registrations are ignored by the single-implementation
+// check, as their conditions are not evaluated; see ValidateDepsWithConfig.
 func ValidateDeps(deps []*Dep) error {
+	return validateDeps(deps, false)
+}
+
+// validateDeps implements ValidateDeps. selected tells whether the deps are
+// the active ones for a config, whose conditions were evaluated.
+func validateDeps(deps []*Dep, selected bool) error {
 	// Gather the set of registered interfaces.
 	intfs := map[reflect.Type]struct{}{}
+	impls := map[reflect.Type][]*Dep{}
 	for _, reg := range deps {
+		if reg.decorator {
+			continue
+		}
 		intfs[reg.iface] = struct{}{}
+		impls[reg.iface] = append(impls[reg.iface], reg)
+	}
+
+	var errs []error
+
+	// Check that the single-implementation interfaces have a single
+	// implementation. Unless selected, the conditional registrations are
+	// ignored, as their conditions are not evaluated.
+	for iface, regs := range impls {
+		if !selected {
+			regs = slices.DeleteFunc(slices.Clone(regs), (*Dep).conditional)
+		}
+		if len(regs) < 2 {
+			continue
+		}
+		for _, reg := range regs {
+			if reg.singleton {
+				names := make([]string, len(regs))
+				for i, reg := range regs {
+					names[i] = reg.name
+				}
+				sort.Strings(names)
+				errs = append(errs, fmt.Errorf(
+					"the interface %v is single-implementation but has %d implementations %v",
+					iface, len(regs), names,
+				))
+				break
+			}
+		}
 	}
 
 	// Check that for every deps.Ref[T] field in an implementation
 	// struct, T is a registered interface.
-	var errs []error
 	for _, dep := range deps {
-		for i := 0; i < dep.impl.NumField(); i++ {
-			f := dep.impl.Field(i)
-			switch {
-			case f.Type.Implements(Type[interface{ isRef() }]()):
-				// f is a deps.Ref[T].
-				v := f.Type.Field(0) // a Ref[T]'s value field
-				if _, ok := intfs[v.Type]; !ok {
-					// T is not a registered runtime interface.
+		if dep.provider.IsValid() {
+			args := resolveArgs(providerArgs(dep.provider.Type()), func(t reflect.Type) bool {
+				_, ok := intfs[t]
+				return ok
+			})
+			for i, arg := range args {
+				if arg.kind != argIntf && arg.kind != argValue {
+					continue
+				}
+				if _, ok := intfs[arg.typ]; !ok {
 					err := fmt.Errorf(
-						"the implementation struct %v has reference field %v, but %v was not registered; maybe you forgot to register it",
-						dep.impl, f.Type, v.Type,
+						"the constructor %v has argument %d of type %v, but %v was not registered; %s",
+						dep.provider.Type(), i, arg.typ, arg.typ, registerHint(arg.typ),
 					)
 					errs = append(errs, err)
 				}
+			}
+			if configArgs(args) > 1 {
+				errs = append(errs, fmt.Errorf("the constructor %v has more than one config argument", dep.provider.Type()))
+			}
+			continue
+		}
+		if dep.impl == nil {
+			// Supplied value.
+			continue
+		}
+		if _, ok := intfs[dep.iface]; dep.decorator && !ok {
+			errs = append(errs, fmt.Errorf(
+				"the decorator %v decorates %v, but %v was not registered; %s",
+				dep.impl, dep.iface, dep.iface, registerHint(dep.iface),
+			))
+		}
+		for _, ref := range depRefs(dep) {
+			if _, ok := intfs[ref.typ]; !ok {
+				// T is not a registered runtime interface.
+				err := fmt.Errorf(
+					"the implementation struct %v has reference field %v, but %v was not registered; %s",
+					dep.impl, ref.field.Type, ref.typ, registerHint(ref.typ),
+				)
+				errs = append(errs, err)
+			}
+		}
+	}
 
+	// Check that the singletons don't refer to scoped deps, which only
+	// exist within a scope.
+	for _, dep := range deps {
+		if dep.decorator || dep.lifetime != Singleton {
+			continue
+		}
+		for _, ref := range depRefs(dep) {
+			for _, d := range deps {
+				if d.lifetime == Scoped && !d.decorator && refersTo(ref, d) {
+					errs = append(errs, fmt.Errorf("the singleton %q refers to the scoped dep %q", dep.label(), d.label()))
+				}
 			}
 		}
 	}
 	return errors.Join(errs...)
 }
+
+// refersTo returns true if ref may be resolved to dep, regardless of the
+// bindings of the config.
+func refersTo(ref refField, dep *Dep) bool {
+	if ref.impl {
+		return dep.impl == ref.typ && (ref.name == "" || ref.name == dep.name)
+	}
+	if dep.iface != ref.typ {
+		return false
+	}
+	if ref.name == "" {
+		return dep.anonymous()
+	}
+	return ref.name == dep.name
+}
+
+// registerHint returns the hint about how to register the type t.
+func registerHint(t reflect.Type) string {
+	if t.Kind() != reflect.Interface {
+		return "maybe you forgot to supply it with deps.Supply"
+	}
+	return "maybe you forgot to register it"
+}
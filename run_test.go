@@ -0,0 +1,130 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type runService interface{}
+
+type runServiceImpl struct {
+	Implements[runService]
+	events *[]string
+}
+
+func (s *runServiceImpl) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (s *runServiceImpl) Shutdown(context.Context) error {
+	*s.events = append(*s.events, "shutdown")
+	return nil
+}
+
+// registerGlobal registers the deps in the global registry, used by Run,
+// until t ends.
+func registerGlobal(t *testing.T, deps ...*Dep) {
+	t.Helper()
+	for _, dep := range deps {
+		dep := dep
+		if err := globalRegistry.register(*dep); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { globalRegistry.remove(dep.iface, nil) })
+	}
+}
+
+type runApp struct {
+	Implements[System]
+	svc Ref[runService]
+}
+
+func TestRunShutsDown(t *testing.T) {
+	var events []string
+	MustProvide[System, runApp]()
+	MustProvideFunc[runService](func() (runService, error) {
+		return &runServiceImpl{events: &events}, nil
+	})
+	t.Cleanup(func() {
+		globalRegistry.remove(Type[System](), nil)
+		globalRegistry.remove(Type[runService](), nil)
+	})
+
+	errStart := errors.New("start failed")
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"ok", nil},
+		{"start failed", errStart},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			events = nil
+			var served context.Context
+			err := Run[runApp](context.Background(), Config{}, func(ctx context.Context, app *runApp) error {
+				served = ctx
+				return tc.err
+			})
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Run() = %v, want %v", err, tc.err)
+			}
+			if served.Err() == nil {
+				t.Error("runtime context not canceled after Run")
+			}
+			if len(events) != 1 || events[0] != "shutdown" {
+				t.Errorf("events = %v, want [shutdown]", events)
+			}
+		})
+	}
+}
+
+type stuckService interface{}
+
+// stuckServiceImpl is shut down when its context is done.
+type stuckServiceImpl struct{}
+
+func (stuckServiceImpl) Shutdown(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type stuckApp struct {
+	Implements[System]
+	svc Ref[stuckService]
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	registerGlobal(t,
+		mustDep(NewDep[System, stuckApp]()),
+		mustDep(NewFuncDep[stuckService](func() stuckService { return stuckServiceImpl{} })),
+	)
+	start := time.Now()
+	err := Run[stuckApp](context.Background(), Config{ShutdownTimeout: 20 * time.Millisecond}, func(context.Context, *stuckApp) error {
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "shutdown incomplete") {
+		t.Errorf("Run() = %v, want the shutdown incomplete error", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Run took %v, want it bounded by the shutdown timeout", d)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("failed"), 1},
+		{context.DeadlineExceeded, 1},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+package deps
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunUntilSignal(t *testing.T) {
+	var events []string
+	registerGlobal(t,
+		mustDep(NewDep[System, runApp]()),
+		mustDep(NewFuncDep[runService](func() (runService, error) {
+			return &runServiceImpl{events: &events}, nil
+		})),
+	)
+
+	var served context.Context
+	err := RunUntilSignal[runApp](context.Background(), Config{}, func(ctx context.Context, app *runApp) error {
+		served = ctx
+		if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			t.Error("context not canceled by SIGTERM")
+			return nil
+		}
+	})
+	if err != nil {
+		t.Errorf("RunUntilSignal() = %v, want nil", err)
+	}
+	if served.Err() == nil {
+		t.Error("runtime context not canceled")
+	}
+	if len(events) != 1 || events[0] != "shutdown" {
+		t.Errorf("events = %v, want [shutdown]", events)
+	}
+}
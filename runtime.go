@@ -5,14 +5,25 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
 // Runtime is the interface for the deps runtime which provides
 // the way to resolve the references or implementations.
+//
+// The runtimes returned by NewRuntime, and their scopes and children, also
+// implement the optional interfaces Contexter, NamedResolver, Timeliner,
+// HealthReporter, Describer, Shutdowner, Scoper and Parent, which are
+// checked with type assertions:
+//
+//	if s, ok := runtime.(deps.Shutdowner); ok {
+//		err = s.Shutdown(ctx)
+//	}
 type Runtime interface {
 	// GetImpl returns the instance of the given type.
 	GetImpl(reflect.Type) (any, error)
@@ -21,24 +32,102 @@ type Runtime interface {
 	GetIntf(reflect.Type, string) (any, error)
 }
 
+// Contexter is implemented by the runtimes. Context returns the context
+// passed to the components. It is canceled when a background service fails
+// or the runtime is shut down.
+type Contexter interface {
+	Context() context.Context
+}
+
+// NamedResolver is implemented by the runtimes. GetNamedImpl returns the
+// instance of the given type registered with the given name.
+type NamedResolver interface {
+	GetNamedImpl(reflect.Type, string) (any, error)
+}
+
+// The optional interfaces implemented by the runtimes.
+var _ interface {
+	Runtime
+	Contexter
+	NamedResolver
+	Timeliner
+	HealthReporter
+	Describer
+	Shutdowner
+	Scoper
+	Parent
+} = (*runtime)(nil)
+
+// capability returns r as the optional interface T, and panics if r doesn't
+// implement it.
+func capability[T any](r Runtime, caller string) T {
+	c, ok := r.(T)
+	if !ok {
+		panic(fmt.Sprintf("deps.%s: %T does not implement %v", caller, r, Type[T]()))
+	}
+	return c
+}
+
 type Config struct {
-	Config  string
-	Fakes   map[reflect.Type]any
-	Present map[string]any
-	Root    *slog.Logger
+	Config string
+	// Fakes replace all the implementations of the interfaces.
+	Fakes map[reflect.Type]any
+	// NamedFakes replace single implementations, and take precedence over
+	// Fakes. An implementation can only be faked once, and must be
+	// registered.
+	NamedFakes []Fake
+	Present    map[string]any
+	Root       *slog.Logger
+	// SlowInit is the threshold above which an Init call is logged as a
+	// warning. Zero disables the warning.
+	SlowInit time.Duration
+	// CheckTimeout bounds every health or readiness check. Zero means
+	// DefaultCheckTimeout.
+	CheckTimeout time.Duration
+	// ShutdownTimeout bounds the shutdown of the components performed by
+	// Run and RunUntilSignal. Zero means DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+	// Profiles are the active profiles, in addition to the ones of the
+	// config. See WithProfiles.
+	Profiles []string
+	// Modules are registered in addition to the given deps.
+	Modules []*ModuleDef
 }
 
 type runtime struct {
 	depsByName map[string]*Dep
 	depsByIntf map[reflect.Type]map[string]*Dep
-	depsByImpl map[reflect.Type]*Dep
+	depsByImpl map[reflect.Type][]*Dep
+	decorators map[reflect.Type][]*Dep // by interface, in registration order
 
 	ctx      context.Context
+	cancel   context.CancelCauseFunc
 	config   Config
 	sections map[string]string
+	rtConfig runtimeConfig
+
+	modSections map[string]map[string]string // module name to its sections
+
+	mu        sync.Mutex
+	impls     map[string]any
+	decorated map[string]any // dep id to its decorated instance
+	timeline  []InitTiming
+	edges     map[string][]string // dep id to the ids of its resolved refs
+	order     []built             // the constructed instances, in construction order
+	resolving []*Dep              // the deps being constructed, see enter
+	failed    []string            // the path of the dep which failed, see leave
+	closed    bool
+	servers   servers
+
+	parent *runtime        // the runtime a scope or a child derives from
+	scope  bool            // true for a scope, see NewScope
+	owned  map[string]bool // for a child, dep ids to whether it is constructed by the child
+}
 
-	mu    sync.Mutex
-	impls map[string]any
+// built is an instance constructed by the runtime.
+type built struct {
+	dep *Dep
+	obj any
 }
 
 // NewRuntime returns a new Runtime.
@@ -50,14 +139,78 @@ func newRuntime(ctx context.Context, deps []*Dep, config Config) (*runtime, erro
 	if err != nil {
 		return nil, err
 	}
+	rtConfig, err := parseRuntimeConfig(sections)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Root == nil {
+		config.Root = slog.Default()
+	}
+	if err := validateFakes(config); err != nil {
+		return nil, err
+	}
+
+	deps, err = withModules(deps, config)
+	if err != nil {
+		return nil, err
+	}
+	deps, selections, err := selectDeps(deps, newConfigView(config, sections, rtConfig))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range selections {
+		if len(s.Candidates) > 1 || s.Candidates[0].Conditional {
+			config.Root.Debug("dep selected", "selection", s)
+		}
+	}
+
+	deps, decorators := splitDecorators(deps)
+	depsByName, depsByIntf, depsByImpl, err := indexDeps(deps)
+	if err != nil {
+		return nil, err
+	}
+	if err := rtConfig.validateBindings(depsByIntf, decorators); err != nil {
+		return nil, err
+	}
+	if err := validateDecorators(decorators, depsByIntf); err != nil {
+		return nil, err
+	}
+	if err := validateNamedFakes(config.NamedFakes, depsByIntf); err != nil {
+		return nil, err
+	}
+
+	impls := map[string]any{}
+	for k, v := range config.Present {
+		impls[k] = v
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &runtime{
+		depsByName: depsByName,
+		depsByIntf: depsByIntf,
+		depsByImpl: depsByImpl,
+		decorators: decorators,
+		ctx:        ctx,
+		cancel:     cancel,
+		config:     config,
+		sections:   sections,
+		rtConfig:   rtConfig,
+		impls:      impls,
+		decorated:  map[string]any{},
+		edges:      map[string][]string{},
+	}, nil
+}
 
+// indexDeps indexes the deps by id, interface and name, and implementation.
+func indexDeps(deps []*Dep) (map[string]*Dep, map[reflect.Type]map[string]*Dep, map[reflect.Type][]*Dep, error) {
 	depsByName := map[string]*Dep{}
 	depsByIntf := map[reflect.Type]map[string]*Dep{}
-	depsByImpl := map[reflect.Type]*Dep{}
+	depsByImpl := map[reflect.Type][]*Dep{}
 	for _, dep := range deps {
 		_, ok := depsByName[dep.id]
 		if ok {
-			return nil, fmt.Errorf("multiple deps found for %s", dep.id)
+			return nil, nil, nil, fmt.Errorf("multiple deps found for %s", dep.id)
 		}
 		depsByName[dep.id] = dep
 
@@ -69,36 +222,21 @@ func newRuntime(ctx context.Context, deps []*Dep, config Config) (*runtime, erro
 
 		_, ok = intfs[dep.name]
 		if ok {
-			return nil, fmt.Errorf("multiple deps implemented the same interface found for %s", dep.name)
+			return nil, nil, nil, fmt.Errorf("multiple deps implemented the same interface found for %s", dep.label())
 		}
 
 		intfs[dep.name] = dep
 
-		_, ok = depsByImpl[dep.impl]
-		if ok {
-			return nil, fmt.Errorf("multiple deps found for the same implementation %v", dep.impl)
+		if dep.impl == nil {
+			// Provided by a constructor function or supplied.
+			continue
 		}
-		depsByImpl[dep.impl] = dep
-	}
-
-	impls := map[string]any{}
-	for k, v := range config.Present {
-		impls[k] = v
-	}
-
-	if config.Root == nil {
-		config.Root = slog.Default()
+		// The same implementation may be registered several times with
+		// different names.
+		depsByImpl[dep.impl] = append(depsByImpl[dep.impl], dep)
 	}
 
-	return &runtime{
-		depsByName: depsByName,
-		depsByIntf: depsByIntf,
-		depsByImpl: depsByImpl,
-		ctx:        ctx,
-		config:     config,
-		sections:   sections,
-		impls:      impls,
-	}, nil
+	return depsByName, depsByIntf, depsByImpl, nil
 }
 
 func (r *runtime) GetImpl(t reflect.Type) (any, error) {
@@ -107,21 +245,68 @@ func (r *runtime) GetImpl(t reflect.Type) (any, error) {
 	return r.getImpl(t)
 }
 
+func (r *runtime) GetNamedImpl(t reflect.Type, name string) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dep, err := r.resolveImpl(t, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.get(dep)
+}
+
 func (r *runtime) GetIntf(t reflect.Type, name string) (any, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.getIntf(t, name, "root")
 }
 
+func (r *runtime) Context() context.Context {
+	return r.ctx
+}
+
+func (r *runtime) Timeline() []InitTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]InitTiming(nil), r.timeline...)
+}
+
 func (r *runtime) getImpl(t reflect.Type) (any, error) {
-	dep, ok := r.depsByImpl[t]
-	if !ok {
-		return nil, fmt.Errorf("the implementation %v not found", t)
+	dep, err := r.resolveImpl(t, "")
+	if err != nil {
+		return nil, err
 	}
 
 	return r.get(dep)
 }
 
+// resolveImpl returns the registration of the implementation t with the
+// given name. The name may be empty if t is registered only once.
+func (r *runtime) resolveImpl(t reflect.Type, name string) (*Dep, error) {
+	deps, ok := r.depsByImpl[t]
+	if !ok {
+		return nil, fmt.Errorf("the implementation %v %w", t, ErrNotRegistered)
+	}
+
+	if name == "" {
+		if len(deps) > 1 {
+			names := make([]string, len(deps))
+			for i, dep := range deps {
+				names[i] = dep.name
+			}
+			return nil, fmt.Errorf("the implementation %v is registered %d times as %v; a name is required", t, len(deps), names)
+		}
+		return deps[0], nil
+	}
+
+	for _, dep := range deps {
+		if dep.name == name {
+			return dep, nil
+		}
+	}
+	return nil, fmt.Errorf("the implementation %v named %q %w", t, name, ErrNotRegistered)
+}
+
 func (r *runtime) hook(reg *Dep, impl any, requester string) (any, error) {
 	if reg.hook != nil {
 		return reg.hook(impl, requester), nil
@@ -130,57 +315,151 @@ func (r *runtime) hook(reg *Dep, impl any, requester string) (any, error) {
 }
 
 func (r *runtime) getIntf(t reflect.Type, name, requester string) (any, error) {
+	dep, err := r.resolveIntf(t, name, requester)
+	if err != nil {
+		return nil, err
+	}
+	return r.getDep(dep, requester)
+}
+
+// getLocked is like get, but locks the runtime.
+func (r *runtime) getLocked(dep *Dep) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.get(dep)
+}
+
+// getDep returns the instance of dep as seen by the requester.
+func (r *runtime) getDep(dep *Dep, requester string) (any, error) {
+	v, err := r.getDecorated(dep)
+	if err != nil {
+		return nil, err
+	}
+	return r.hook(dep, v, requester)
+}
+
+// resolveIntf returns the registration of the implementation of the interface t
+// with the given name, as bound for the requester by the config.
+func (r *runtime) resolveIntf(t reflect.Type, name, requester string) (*Dep, error) {
 	deps, ok := r.depsByIntf[t]
 	if !ok {
-		return nil, fmt.Errorf("dep %v not found; maybe you forgot to register the Registration", t)
+		return nil, fmt.Errorf("dep %v %w; maybe you forgot to register the Registration", t, ErrNotRegistered)
 	}
 
-	get := func(reg *Dep) (any, error) {
-		v, err := r.get(reg)
-		if err != nil {
-			return nil, err
-		}
-		return r.hook(reg, v, requester)
-	}
+	name = r.rtConfig.bind(t, name, requester)
 
 	if name != "" {
 		dep, ok := deps[name]
 		if !ok {
-			return nil, fmt.Errorf("dep %v not found; maybe you forgot to register the Registration", t)
+			return nil, fmt.Errorf("dep %v %w; maybe you forgot to register the Registration", t, ErrNotRegistered)
 		}
-		return get(dep)
+		return dep, nil
 	}
 
-	var dep *Dep
 	for _, v := range deps {
-		if v.name == v.id {
-			// Anonymous dependency
-			dep = v
-			break
+		if v.anonymous() {
+			return v, nil
 		}
 	}
 
-	if dep == nil {
-		return nil, fmt.Errorf("no anonymous dep found for %v", t)
-	}
-
-	return get(dep)
+	return nil, fmt.Errorf("anonymous dep %v %w", t, ErrNotRegistered)
 }
 
 func (r *runtime) get(dep *Dep) (any, error) {
+	switch dep.lifetime {
+	case Singleton:
+		if r.parent != nil && !r.owns(dep) {
+			return r.parent.getLocked(dep)
+		}
+	case Scoped:
+		if !r.scope {
+			return nil, fmt.Errorf("dep %q is scoped and can only be requested within a scope", dep.label())
+		}
+	}
+
 	if c, ok := r.impls[dep.id]; ok {
 		return c, nil
 	}
 
-	if fake, ok := r.config.Fakes[dep.iface]; ok {
+	if r.closed {
+		return nil, fmt.Errorf("dep %q requested after the runtime was shut down", dep.label())
+	}
+
+	if fake, ok := r.fake(dep); ok {
 		return fake, nil
 	}
 
+	if dep.value.IsValid() {
+		// Supplied values are owned by the caller.
+		obj := dep.value.Interface()
+		r.impls[dep.id] = obj
+		return obj, nil
+	}
+
+	obj, err := r.build(dep, nil, dep.lifetime)
+	if err != nil {
+		return nil, err
+	}
+	if dep.lifetime != Transient {
+		r.impls[dep.id] = obj
+	}
+	return obj, nil
+}
+
+// build constructs a new instance of dep with the given lifetime, and starts
+// its background service. For a decorator, inner is the decorated instance
+// and lifetime is the one of the decorated dep.
+func (r *runtime) build(dep *Dep, inner any, lifetime Lifetime) (obj any, err error) {
+	if err := r.enter(dep); err != nil {
+		return nil, err
+	}
+	defer func() { err = r.leave(err) }()
+
+	timing := InitTiming{ID: dep.id, Name: dep.name, Impl: dep.implType(), Start: time.Now()}
+	if dep.provider.IsValid() {
+		obj, err = r.call(dep, &timing)
+	} else {
+		obj, err = r.construct(dep, &timing, inner)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.config.SlowInit > 0 && timing.Init > r.config.SlowInit {
+		r.config.Root.Warn("slow dep initialization",
+			"dep", dep.label(), "init", timing.Init, "threshold", r.config.SlowInit)
+	}
+
+	if _, ok := obj.(Server); ok && lifetime == Transient {
+		return nil, fmt.Errorf("dep %q is transient and can't run a background service", dep.label())
+	}
+	if lifetime != Transient || r.scope {
+		// The transient instances are only tracked by the scopes, which
+		// shut them down.
+		r.timeline = append(r.timeline, timing)
+		r.order = append(r.order, built{dep, obj})
+	}
+
+	if s, ok := obj.(Server); ok {
+		r.serve(dep, s)
+	}
+	return obj, nil
+}
+
+// construct constructs the implementation struct of dep. For a decorator,
+// its refs to the decorated interface are set to inner.
+func (r *runtime) construct(dep *Dep, timing *InitTiming, inner any) (any, error) {
 	v := reflect.New(dep.impl)
 	obj := v.Interface()
 
 	// Setup
-	if err := setupConfig(dep.name, v, r.sections); err != nil {
+	if err := timed(&timing.Config, func() error {
+		sections, err := r.sectionsOf(dep)
+		if err != nil {
+			return err
+		}
+		return setupConfig(dep.name, dep.section, v, sections)
+	}); err != nil {
 		return nil, err
 	}
 
@@ -190,22 +469,45 @@ func (r *runtime) get(dep *Dep) (any, error) {
 		return nil, err
 	}
 
-	if err := setupRefs(obj, func(t reflect.Type, name string) (any, error) {
-		return r.getIntf(t, name, dep.name)
+	if err := timed(&timing.Refs, func() error {
+		return setupRefs(obj, func(t reflect.Type, name string) (any, error) {
+			if dep.decorator && t == dep.iface {
+				return inner, nil
+			}
+			ref, err := r.resolveIntf(t, name, dep.name)
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.getDep(ref, dep.name)
+			if err != nil {
+				return nil, err
+			}
+			r.addEdge(dep, ref)
+			return v, nil
+		})
 	}); err != nil {
 		return nil, err
 	}
 
 	// Call the Init method.
 	if i, ok := obj.(interface{ Init(context.Context) error }); ok {
-		if err := i.Init(r.ctx); err != nil {
-			return nil, fmt.Errorf("dep %q initialization failed: %w", dep.name, err)
+		if err := timed(&timing.Init, func() error {
+			return i.Init(r.ctx)
+		}); err != nil {
+			return nil, &InitError{Dep: dep.label(), Err: err}
 		}
 	}
-	r.impls[dep.id] = obj
+
 	return obj, nil
 }
 
+// addEdge records that dep refers to the resolved ref.
+func (r *runtime) addEdge(dep, ref *Dep) {
+	if !slices.Contains(r.edges[dep.id], ref.id) {
+		r.edges[dep.id] = append(r.edges[dep.id], ref.id)
+	}
+}
+
 // ParseTOML parses the provided TOML input and returns a map of sections.
 func ParseTOML(input string) (map[string]string, error) {
 	var sections map[string]toml.Primitive
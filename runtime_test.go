@@ -0,0 +1,29 @@
+package deps
+
+import (
+	"context"
+	"testing"
+)
+
+// mustDep returns a pointer to dep, and panics on error.
+func mustDep(dep Dep, err error) *Dep {
+	if err != nil {
+		panic(err)
+	}
+	return &dep
+}
+
+// testRuntime returns a runtime of the given deps, shut down when t ends.
+func testRuntime(t *testing.T, config Config, deps ...*Dep) *runtime {
+	t.Helper()
+	r, err := newRuntime(context.Background(), deps, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+	return r
+}
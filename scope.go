@@ -0,0 +1,83 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+)
+
+// Lifetime is the lifetime of the instances of a dep.
+type Lifetime int
+
+const (
+	// Singleton deps have a single instance, shared by the runtime and all
+	// its scopes. This is the default.
+	Singleton Lifetime = iota
+	// Transient deps have a new instance for every injection: every
+	// deps.Ref field, constructor argument or GetIntf call. The instances
+	// are shut down with the scope which constructed them; outside a scope
+	// they are not tracked by the runtime, and are released by their
+	// consumers. Transient deps can't run a background service.
+	Transient
+	// Scoped deps have one instance per scope, see Scoper.NewScope. They
+	// can't be requested outside a scope, and so can't be referred by
+	// singletons.
+	Scoped
+)
+
+// String implements fmt.Stringer.
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case Scoped:
+		return "scoped"
+	}
+	return fmt.Sprintf("Lifetime(%d)", int(l))
+}
+
+// WithScope sets the lifetime of the instances of the Dep.
+func WithScope(l Lifetime) Option {
+	return func(dep *Dep) {
+		dep.lifetime = l
+	}
+}
+
+// Scoper is implemented by the runtimes. NewScope returns a scope deriving
+// from the runtime. See Scoped.
+type Scoper interface {
+	NewScope(context.Context) Runtime
+}
+
+// NewScope returns a scope deriving from the runtime, typically for a single
+// request. The Scoped deps requested from the scope are constructed within
+// it, with ctx as the context, while the singletons are shared with the
+// runtime. The transient instances and the scoped ones are released by
+// calling the Shutdown method of the scope.
+//
+//	func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+//		scope := h.runtime.(deps.Scoper).NewScope(req.Context())
+//		defer scope.(deps.Shutdowner).Shutdown(context.Background())
+//		session, err := scope.GetIntf(deps.Type[Session](), "")
+//		...
+//	}
+func (r *runtime) NewScope(ctx context.Context) Runtime {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &runtime{
+		depsByName: r.depsByName,
+		depsByIntf: r.depsByIntf,
+		depsByImpl: r.depsByImpl,
+		decorators: r.decorators,
+		ctx:        ctx,
+		cancel:     cancel,
+		config:     r.config,
+		sections:   r.sections,
+		rtConfig:   r.rtConfig,
+		impls:      map[string]any{},
+		decorated:  map[string]any{},
+		edges:      map[string][]string{},
+		parent:     r,
+		scope:      true,
+	}
+}
@@ -0,0 +1,120 @@
+package deps
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type lifeFoo interface{}
+
+type lifeFooImpl struct {
+	Implements[lifeFoo]
+	closed bool
+}
+
+func (f *lifeFooImpl) Shutdown(context.Context) error {
+	f.closed = true
+	return nil
+}
+
+type lifeBar interface{}
+
+type lifeBarImpl struct {
+	Implements[lifeBar]
+	foo Ref[lifeFoo]
+}
+
+type lifeServer struct {
+	Implements[lifeFoo]
+}
+
+func (s *lifeServer) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestLifetimes(t *testing.T) {
+	for _, tc := range []struct {
+		lifetime     Lifetime
+		sameInScope  bool // the same instance twice in a scope
+		sameAsParent bool // the same instance in a scope and in another one
+		outside      bool // can be requested outside a scope
+	}{
+		{lifetime: Singleton, sameInScope: true, sameAsParent: true, outside: true},
+		{lifetime: Transient, outside: true},
+		{lifetime: Scoped, sameInScope: true},
+	} {
+		t.Run(tc.lifetime.String(), func(t *testing.T) {
+			r := testRuntime(t, Config{}, mustDep(NewDep[lifeFoo, lifeFooImpl](WithScope(tc.lifetime))))
+			get := func(rt Runtime) *lifeFooImpl {
+				t.Helper()
+				v, err := rt.GetIntf(Type[lifeFoo](), "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return v.(*lifeFooImpl)
+			}
+
+			if _, err := r.GetIntf(Type[lifeFoo](), ""); (err == nil) != tc.outside {
+				t.Errorf("GetIntf outside a scope: %v", err)
+			}
+			if tc.lifetime == Transient && len(r.order) != 0 {
+				t.Errorf("transient instance tracked outside a scope")
+			}
+
+			s1, s2 := r.NewScope(context.Background()), r.NewScope(context.Background())
+			a, b, c := get(s1), get(s1), get(s2)
+			if (a == b) != tc.sameInScope {
+				t.Errorf("same instance in a scope: %v, want %v", a == b, tc.sameInScope)
+			}
+			if (a == c) != tc.sameAsParent {
+				t.Errorf("same instance in two scopes: %v, want %v", a == c, tc.sameAsParent)
+			}
+
+			if err := s1.(Shutdowner).Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			// The scope only shuts down what it constructed.
+			if want := tc.lifetime != Singleton; a.closed != want || b.closed != want {
+				t.Errorf("instances closed by the scope: %v, %v, want %v", a.closed, b.closed, want)
+			}
+			if c.closed {
+				t.Error("instance of another scope closed")
+			}
+		})
+	}
+}
+
+func TestTransientServer(t *testing.T) {
+	r := testRuntime(t, Config{}, mustDep(NewDep[lifeFoo, lifeServer](WithScope(Transient))))
+	_, err := r.GetIntf(Type[lifeFoo](), "")
+	if err == nil || !strings.Contains(err.Error(), "can't run a background service") {
+		t.Errorf("GetIntf() = %v, want the background service error", err)
+	}
+}
+
+func TestValidateLifetimes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		bar     Lifetime
+		foo     Lifetime
+		wantErr bool
+	}{
+		{"singleton to singleton", Singleton, Singleton, false},
+		{"singleton to transient", Singleton, Transient, false},
+		{"singleton to scoped", Singleton, Scoped, true},
+		{"scoped to scoped", Scoped, Scoped, false},
+		{"transient to scoped", Transient, Scoped, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateDeps([]*Dep{
+				mustDep(NewDep[lifeFoo, lifeFooImpl](WithScope(tc.foo))),
+				mustDep(NewDep[lifeBar, lifeBarImpl](WithScope(tc.bar))),
+			})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateDeps() = %v, want error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
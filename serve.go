@@ -0,0 +1,94 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errShutdown is the cause of the cancellation of the runtime context when
+// the runtime is shut down.
+var errShutdown = errors.New("runtime shut down")
+
+// Server is implemented by the implementations which run a background
+// service for their whole life, like a consumer or a scheduler.
+//
+// The runtime calls Serve in a dedicated goroutine right after Init. The
+// context passed to Serve is canceled when the runtime is shut down, and
+// Serve is expected to return promptly then; its result is ignored. If Serve returns an error
+// before that, the service is restarted according to its RestartPolicy; if
+// it is not restarted, the runtime context is canceled so that the whole
+// system stops, like an errgroup.
+type Server interface {
+	Serve(context.Context) error
+}
+
+// servers supervises the running background services.
+type servers struct {
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error // the first error
+
+	mu       sync.Mutex
+	restarts map[string]int // dep id to the number of restarts
+}
+
+// restarted counts a restart of the service of the dep with the given id,
+// and returns the number of its restarts.
+func (s *servers) restarted(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.restarts == nil {
+		s.restarts = map[string]int{}
+	}
+	s.restarts[id]++
+	return s.restarts[id]
+}
+
+// restartCount returns the number of restarts of the service of the dep
+// with the given id.
+func (s *servers) restartCount(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts[id]
+}
+
+// fail records err if it is the first error.
+func (s *servers) fail(err error) {
+	s.once.Do(func() { s.err = err })
+}
+
+// wait waits for all the services to return or ctx to be done, and returns
+// the first error.
+func (s *servers) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return s.err
+}
+
+// serve runs the background service of dep.
+func (r *runtime) serve(dep *Dep, s Server) {
+	r.servers.wg.Add(1)
+	go func() {
+		defer r.servers.wg.Done()
+		err := r.supervise(dep, s)
+		if err == nil || r.ctx.Err() != nil {
+			// The service was asked to stop, by the shutdown, the parent
+			// context or another service, so whatever it returns, like
+			// http.ErrServerClosed, is a clean stop.
+			return
+		}
+		err = fmt.Errorf("dep %q serve failed: %w", dep.label(), err)
+		r.config.Root.Error("background service failed", "dep", dep.label(), "err", err)
+		r.servers.fail(err)
+		r.cancel(err)
+	}()
+}
@@ -0,0 +1,76 @@
+package deps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stopService interface{}
+
+// stopServer serves until its context is done, then returns the result of
+// stop.
+type stopServer struct {
+	stop    func(ctx context.Context) error
+	serving chan struct{}
+}
+
+func (s *stopServer) Serve(ctx context.Context) error {
+	close(s.serving)
+	<-ctx.Done()
+	return s.stop(ctx)
+}
+
+func TestServeStop(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		stop   func(ctx context.Context) error
+		parent bool // cancel the parent context instead of shutting down
+	}{
+		{"nil", func(context.Context) error { return nil }, false},
+		{"canceled", func(ctx context.Context) error { return ctx.Err() }, false},
+		{"cause", func(ctx context.Context) error { return context.Cause(ctx) }, false},
+		{"server closed", func(context.Context) error { return errors.New("server closed") }, false},
+		{"parent canceled", func(context.Context) error { return errors.New("server closed") }, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &stopServer{stop: tc.stop, serving: make(chan struct{})}
+			dep := mustDep(NewFuncDep[stopService](func() stopService { return s }))
+			var logs bytes.Buffer
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r, err := newRuntime(ctx, []*Dep{dep}, Config{
+				Root: slog.New(slog.NewTextHandler(&logs, nil)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.GetIntf(Type[stopService](), ""); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-s.serving:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout")
+			}
+
+			if tc.parent {
+				cancel()
+				// Wait for the service to return.
+				if err := r.servers.wait(context.Background()); err != nil {
+					t.Errorf("serve error %v, want nil", err)
+				}
+			}
+			if err := r.Shutdown(context.Background()); err != nil {
+				t.Errorf("Shutdown() = %v, want nil", err)
+			}
+			if strings.Contains(logs.String(), "background service failed") {
+				t.Errorf("logged a failure:\n%s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultShutdownTimeout is the shutdown deadline used by Run and
+// RunUntilSignal when Config.ShutdownTimeout is zero.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Shutdowner is implemented by the implementations which need to release
+// resources when the runtime is shut down, and by the runtimes.
+type Shutdowner interface {
+	Shutdown(context.Context) error
+}
+
+// Shutdown stops the background services, then calls the Shutdown method of
+// every constructed implementation in the reverse order of construction, so
+// that an implementation is shut down before the implementations it refers
+// to. It stops calling the remaining hooks once ctx is done. No dep can be
+// constructed after Shutdown.
+//
+// The returned error includes the error of the first failed background
+// service, if any.
+func (r *runtime) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	r.cancel(errShutdown)
+	serveErr := r.servers.wait(ctx)
+	if ctx.Err() != nil {
+		return errors.Join(serveErr, fmt.Errorf("shutdown incomplete: %w", ctx.Err()))
+	}
+
+	r.mu.Lock()
+	var hooks []func() error
+	for i := len(r.order) - 1; i >= 0; i-- {
+		dep := r.order[i].dep
+		shutdown := shutdownFunc(dep, r.order[i].obj)
+		if shutdown == nil {
+			continue
+		}
+		hooks = append(hooks, func() error {
+			if err := shutdown(ctx); err != nil {
+				return fmt.Errorf("dep %q shutdown failed: %w", dep.label(), err)
+			}
+			return nil
+		})
+	}
+	r.order = nil
+	r.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
+		for _, hook := range hooks {
+			if ctx.Err() != nil {
+				break
+			}
+			if err := hook(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		done <- errors.Join(errs...)
+	}()
+
+	select {
+	case err := <-done:
+		if ctx.Err() != nil {
+			return errors.Join(serveErr, err, fmt.Errorf("shutdown incomplete: %w", ctx.Err()))
+		}
+		return errors.Join(serveErr, err)
+	case <-ctx.Done():
+		return errors.Join(serveErr, fmt.Errorf("shutdown incomplete: %w", ctx.Err()))
+	}
+}
+
+// shutdownFunc returns the shutdown hook of the instance of dep, or nil. The
+// values returned by constructor functions may also be shut down with their
+// Close method.
+func shutdownFunc(dep *Dep, impl any) func(context.Context) error {
+	if s, ok := impl.(Shutdowner); ok {
+		return s.Shutdown
+	}
+	if c, ok := impl.(io.Closer); ok && dep.provider.IsValid() {
+		return func(context.Context) error { return c.Close() }
+	}
+	return nil
+}
+
+// ExitCode returns the process exit code for the error returned by Run or
+// RunUntilSignal: 0 for nil and 1 otherwise.
+//
+//	func main() {
+//		err := deps.RunUntilSignal[app](ctx, config, serve)
+//		if err != nil {
+//			slog.Error("app failed", "err", err)
+//		}
+//		os.Exit(deps.ExitCode(err))
+//	}
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return 1
+}
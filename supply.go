@@ -0,0 +1,54 @@
+package deps
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NewValueDep returns the Dep of the value supplied for T. See Supply.
+func NewValueDep[T any](value T, opts ...Option) (Dep, error) {
+	t := Type[T]()
+	v := reflect.ValueOf(&value).Elem()
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return Dep{}, fmt.Errorf("nil value supplied for %v", t)
+		}
+	}
+
+	dep := Dep{iface: t, value: v}
+	for _, o := range opts {
+		o(&dep)
+	}
+	dep.initNames()
+	return dep, nil
+}
+
+// Supply registers value as the instance of T, which may be any type, so
+// that shared values like a *slog.Logger, a *http.Client or a Clock struct
+// can be injected with a deps.Ref[T] field or a constructor function
+// argument:
+//
+//	deps.MustSupply[*http.Client](&http.Client{Timeout: time.Second})
+//
+//	type foo struct {
+//		deps.Implements[Foo]
+//		client deps.Ref[*http.Client]
+//	}
+//
+// The supplied value is owned by the caller: it is neither initialized nor
+// shut down by the runtime.
+func Supply[T any](value T, opts ...Option) error {
+	dep, err := NewValueDep[T](value, opts...)
+	if err != nil {
+		return err
+	}
+	return globalRegistry.register(dep)
+}
+
+// MustSupply is like Supply but panics on error.
+func MustSupply[T any](value T, opts ...Option) {
+	if err := Supply[T](value, opts...); err != nil {
+		panic(err)
+	}
+}
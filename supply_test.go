@@ -0,0 +1,155 @@
+package deps
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type supplyIface interface{}
+type supplyFunc interface{}
+
+// supplyClock is a struct value supplied to the graph.
+type supplyClock struct {
+	Zone string
+}
+
+type supplyImpl struct {
+	Implements[supplyIface]
+	client Ref[*http.Client]
+	clock  Ref[supplyClock]
+}
+
+type supplyArgs struct {
+	client *http.Client
+	clock  supplyClock
+}
+
+// supplyCloser is a supplied value with a shutdown hook.
+type supplyCloser struct {
+	shutdown bool
+}
+
+func (s *supplyCloser) Shutdown(context.Context) error {
+	s.shutdown = true
+	return nil
+}
+
+func TestSupply(t *testing.T) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	closer := &supplyCloser{}
+	r, err := newRuntime(context.Background(), []*Dep{
+		mustDep(NewValueDep(client)),
+		mustDep(NewValueDep(supplyClock{Zone: "UTC"})),
+		mustDep(NewValueDep(closer)),
+		mustDep(NewDep[supplyIface, supplyImpl]()),
+		mustDep(NewFuncDep[supplyFunc](func(client *http.Client, clock supplyClock, _ *supplyCloser) supplyFunc {
+			return &supplyArgs{client, clock}
+		})),
+	}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := r.GetIntf(Type[supplyIface](), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impl := v.(*supplyImpl)
+	if impl.client.Get() != client || impl.clock.Get().Zone != "UTC" {
+		t.Errorf("refs %v, %v; want the supplied values", impl.client.Get(), impl.clock.Get())
+	}
+
+	v, err = r.GetIntf(Type[supplyFunc](), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := v.(*supplyArgs)
+	if args.client != client || args.clock.Zone != "UTC" {
+		t.Errorf("arguments %v, %v; want the supplied values", args.client, args.clock)
+	}
+
+	// The supplied values are owned by the caller.
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if closer.shutdown {
+		t.Error("supplied value shut down by the runtime")
+	}
+}
+
+func TestSupplyNil(t *testing.T) {
+	for name, f := range map[string]func() error{
+		"pointer": func() error {
+			_, err := NewValueDep[*http.Client](nil)
+			return err
+		},
+		"interface": func() error {
+			_, err := NewValueDep[io.Reader](nil)
+			return err
+		},
+		"map": func() error {
+			_, err := NewValueDep[map[string]int](nil)
+			return err
+		},
+		"func": func() error {
+			_, err := NewValueDep[func()](nil)
+			return err
+		},
+		"supply": func() error {
+			return Supply[*http.Client](nil)
+		},
+	} {
+		if err := f(); err == nil || !strings.Contains(err.Error(), "nil value supplied") {
+			t.Errorf("%s: error %v, want the nil value error", name, err)
+		}
+	}
+}
+
+func TestValidateSupplied(t *testing.T) {
+	fn := mustDep(NewFuncDep[supplyFunc](func(clock supplyClock) supplyFunc { return nil }))
+	impl := mustDep(NewDep[supplyIface, supplyImpl]())
+	for _, tc := range []struct {
+		name string
+		deps []*Dep
+		errs []string
+	}{
+		{
+			name: "supplied",
+			deps: []*Dep{
+				impl, fn,
+				mustDep(NewValueDep(&http.Client{})),
+				mustDep(NewValueDep(supplyClock{})),
+			},
+		},
+		{
+			name: "not supplied",
+			deps: []*Dep{impl, fn},
+			errs: []string{
+				"reference field deps.Ref[*net/http.Client], but *http.Client was not registered; maybe you forgot to supply it with deps.Supply",
+				"argument 0 of type deps.supplyClock, but deps.supplyClock was not registered; maybe you forgot to supply it with deps.Supply",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateDeps(tc.deps)
+			if len(tc.errs) == 0 {
+				if err != nil {
+					t.Errorf("ValidateDeps() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateDeps() = nil, want errors")
+			}
+			for _, want := range tc.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateDeps() = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
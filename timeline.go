@@ -0,0 +1,53 @@
+package deps
+
+import (
+	"reflect"
+	"time"
+)
+
+// Timeliner is implemented by the runtimes. Timeline returns the
+// construction timings of the implementations in the order they finished
+// their initialization.
+type Timeliner interface {
+	Timeline() []InitTiming
+}
+
+// InitTiming records the time the runtime spent constructing a single
+// implementation.
+type InitTiming struct {
+	// ID is the unique id of the dependency.
+	ID string
+	// Name is the human-readable name of the dependency.
+	Name string
+	// Impl is the implementation type.
+	Impl reflect.Type
+	// Start is the time at which the construction started.
+	Start time.Time
+	// Config is the time spent decoding and validating the config.
+	Config time.Duration
+	// Refs is the time spent resolving the deps.Ref fields. It includes the
+	// time spent constructing the referenced implementations which were not
+	// constructed yet.
+	Refs time.Duration
+	// Init is the time spent in the Init method.
+	Init time.Duration
+}
+
+// Total returns the whole time spent constructing the implementation.
+func (t InitTiming) Total() time.Duration {
+	return t.Config + t.Refs + t.Init
+}
+
+// Self returns the time spent constructing the implementation, excluding
+// the time spent resolving its references.
+func (t InitTiming) Self() time.Duration {
+	return t.Config + t.Init
+}
+
+// timed runs f and adds the elapsed time to d.
+func timed(d *time.Duration, f func() error) error {
+	start := time.Now()
+	err := f()
+	*d += time.Since(start)
+	return err
+}
@@ -0,0 +1,130 @@
+package deps
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowDelay is the time spent in the Init method of slowImpl.
+const slowDelay = 30 * time.Millisecond
+
+type slowIface interface{}
+type timedIface interface{}
+
+type slowImpl struct {
+	Implements[slowIface]
+}
+
+func (s *slowImpl) Init(context.Context) error {
+	time.Sleep(slowDelay)
+	return nil
+}
+
+type timedConfig struct {
+	Name string
+}
+
+type timedImpl struct {
+	Implements[timedIface]
+	WithConfig[timedConfig] `section:"timed"`
+	slow                    Ref[slowIface]
+}
+
+// recordingHandler is a slog.Handler recording the logged records.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+// attrs returns the attributes of the records with the given message.
+func (h *recordingHandler) attrs(msg string) []map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var attrs []map[string]slog.Value
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		m := map[string]slog.Value{}
+		r.Attrs(func(a slog.Attr) bool {
+			m[a.Key] = a.Value
+			return true
+		})
+		attrs = append(attrs, m)
+	}
+	return attrs
+}
+
+func TestTimeline(t *testing.T) {
+	h := &recordingHandler{}
+	r := testRuntime(t, Config{
+		Config:   "[timed]\nname = \"timed\"\n",
+		Root:     slog.New(h),
+		SlowInit: slowDelay / 2,
+	},
+		mustDep(NewDep[slowIface, slowImpl]()),
+		mustDep(NewDep[timedIface, timedImpl]()),
+	)
+	if _, err := r.GetIntf(Type[timedIface](), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The referenced implementation finishes its initialization first.
+	timeline := r.Timeline()
+	if len(timeline) != 2 {
+		t.Fatalf("timeline %v, want 2 entries", timeline)
+	}
+	slow, timed := timeline[0], timeline[1]
+	if slow.ID != typeName(Type[slowIface]()) || timed.ID != typeName(Type[timedIface]()) {
+		t.Fatalf("timeline %s, %s; want slow then timed", slow.ID, timed.ID)
+	}
+	if slow.Impl != Type[slowImpl]() {
+		t.Errorf("impl %v, want slowImpl", slow.Impl)
+	}
+	if timed.Start.After(slow.Start) {
+		t.Errorf("timed started at %v, after slow at %v", timed.Start, slow.Start)
+	}
+
+	if slow.Init < slowDelay || slow.Refs >= slowDelay {
+		t.Errorf("slow: init %v, refs %v; want init >= %v and no refs", slow.Init, slow.Refs, slowDelay)
+	}
+	// The refs of timed include the construction of slow.
+	if timed.Refs < slow.Init || timed.Init >= slowDelay || timed.Config == 0 {
+		t.Errorf("timed: config %v, refs %v, init %v; want config, and refs including %v", timed.Config, timed.Refs, timed.Init, slow.Init)
+	}
+	if timed.Total() != timed.Config+timed.Refs+timed.Init || timed.Self() != timed.Config+timed.Init {
+		t.Errorf("timed: total %v, self %v, inconsistent with %+v", timed.Total(), timed.Self(), timed)
+	}
+
+	// Only the slow Init is logged.
+	warnings := h.attrs("slow dep initialization")
+	if len(warnings) != 1 {
+		t.Fatalf("%d slow init warnings, want 1", len(warnings))
+	}
+	w := warnings[0]
+	if got := w["dep"].String(); got != typeName(Type[slowIface]()) {
+		t.Errorf("slow dep %q, want slowIface", got)
+	}
+	if got := w["threshold"].Duration(); got != slowDelay/2 {
+		t.Errorf("threshold %v, want %v", got, slowDelay/2)
+	}
+	if got := w["init"].Duration(); got < slowDelay {
+		t.Errorf("init %v, want >= %v", got, slowDelay)
+	}
+}